@@ -0,0 +1,61 @@
+package ip2proxy
+
+import (
+	"context"
+	"net"
+	"sync"
+)
+
+// BatchResult is the lookup result of an addr sent to BatchWorkers
+type BatchResult struct {
+	IP     net.IP
+	Result *Result
+	Err    error
+}
+
+// BatchWorkers lookups the ipv4 addrs received from ips with n goroutines sharing the db, and sends their results to
+// the returned channel, in no particular order, the ipv6 addrs getting ErrInvalidIP.
+// The returned channel is closed once ips is closed and all its addrs are looked up, or once ctx is done: the workers
+// then stop, dropping the pending addrs and results. The caller must either read the results until the channel is
+// closed or cancel ctx, for the workers not to leak.
+func (db *DB) BatchWorkers(ctx context.Context, n int, ips <-chan net.IP) <-chan BatchResult {
+	if n < 1 {
+		n = 1
+	}
+	results := make(chan BatchResult, n)
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			db.batchWorker(ctx, ips, results)
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	return results
+}
+
+// lookups the addrs received from ips until it is closed or ctx is done
+func (db *DB) batchWorker(ctx context.Context, ips <-chan net.IP, results chan<- BatchResult) {
+	for {
+		var ip net.IP
+		var ok bool
+		select {
+		case <-ctx.Done():
+			return
+		case ip, ok = <-ips:
+			if !ok {
+				return
+			}
+		}
+		res, err := db.lookupNetIPV4(ip)
+		select {
+		case <-ctx.Done():
+			return
+		case results <- BatchResult{IP: ip, Result: res, Err: err}:
+		}
+	}
+}
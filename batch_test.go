@@ -0,0 +1,70 @@
+package ip2proxy_test
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/etf1/ip2proxy"
+)
+
+var _ = Describe("BatchWorkers", func() {
+	var db *DB
+	BeforeEach(func() {
+		var err error
+		db, err = Open(filepath.Join("testdata", "PX11-SAMPLE.BIN"))
+		Expect(err).To(BeNil())
+	})
+	It("should lookup every addr", func() {
+		ips := make(chan net.IP)
+		go func() {
+			defer close(ips)
+			for _, ip := range []string{"1.0.0.1", "1.0.1.0", "2.0.0.1", "3.0.0.1", "4.0.0.0", "9.9.9.9"} {
+				ips <- net.ParseIP(ip)
+			}
+			ips <- nil
+			// its low 32 bits being 1.0.0.1
+			ips <- net.ParseIP("2001:db8::100:1")
+		}()
+		proxies := map[string]ProxyType{}
+		errs := 0
+		for r := range db.BatchWorkers(context.Background(), 4, ips) {
+			if r.Err != nil {
+				Expect(r.IP.To4()).To(BeNil())
+				Expect(r.Err).To(Equal(ErrInvalidIP))
+				errs++
+				continue
+			}
+			proxies[r.IP.String()] = r.Result.Proxy
+		}
+		Expect(errs).To(Equal(2))
+		Expect(proxies).To(Equal(map[string]ProxyType{
+			"1.0.0.1": ProxyVPN,
+			"1.0.1.0": ProxyTOR,
+			"2.0.0.1": ProxyDCH,
+			"3.0.0.1": ProxyPUB,
+			"4.0.0.0": ProxyWEB,
+			"9.9.9.9": ProxyNOT,
+		}))
+	})
+	It("should stop once the context is done", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		ips := make(chan net.IP)
+		go func() {
+			for {
+				select {
+				case ips <- net.ParseIP("1.0.0.1"):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		results := db.BatchWorkers(ctx, 2, ips)
+		Expect((<-results).Result.Proxy).To(Equal(ProxyVPN))
+		cancel()
+		Eventually(results).Should(BeClosed())
+	})
+})
@@ -0,0 +1,377 @@
+package ip2proxy
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+// header and index sizes of built files
+const (
+	builderHeaderSize = 64
+	builderIndexSize  = maxIndexes * 8
+)
+
+// Builder builds a db file from records.
+//
+// In dbs with a proxy type, addrs not covered by a record are non proxy addrs.
+type Builder struct {
+	t       DbType
+	date    time.Time
+	records []builderRecord
+}
+
+// a record to build
+type builderRecord struct {
+	from uint32
+	to   uint32
+	res  *Result
+}
+
+// NewBuilder creates a builder for a db of type t, dated date
+func NewBuilder(t DbType, date time.Time) (*Builder, error) {
+	if t < PX1 || t > PX11 {
+		return nil, fmt.Errorf("unknown db type %d", t)
+	}
+	if date.Year() < 2000 || date.Year() > 2255 {
+		return nil, fmt.Errorf("date %s out of range", date.Format("2006-01-02"))
+	}
+	return &Builder{t: t, date: date}, nil
+}
+
+// Add adds a record holding res fields for the addrs from from to to included.
+// The IP field of res is ignored, as well as its fields not held by the db type. The stored strings are limited to 255
+// bytes by the db format.
+func (b *Builder) Add(from, to uint32, res *Result) error {
+	if from > to {
+		return fmt.Errorf("invalid range %s-%s", intToIPV4(from), intToIPV4(to))
+	}
+	if from == math.MaxUint32 {
+		return fmt.Errorf("cannot hold a record starting at %s", intToIPV4(from))
+	}
+	if proxytypePos[b.t] != 0 && proxyName(res) == "-" {
+		return fmt.Errorf("record %s-%s is not a proxy, non proxy addrs must be left out", intToIPV4(from), intToIPV4(to))
+	}
+	for _, str := range b.storedStrings(res) {
+		if len(str) > math.MaxUint8 {
+			return fmt.Errorf(
+				"record %s-%s holds a %d bytes string, longer than %d bytes",
+				intToIPV4(from), intToIPV4(to), len(str), math.MaxUint8,
+			)
+		}
+	}
+	b.records = append(b.records, builderRecord{from: from, to: to, res: res})
+	return nil
+}
+
+// Bytes builds the db file
+func (b *Builder) Bytes() ([]byte, error) {
+	rows, err := b.rows()
+	if err != nil {
+		return nil, err
+	}
+	cols := b.cols()
+	rowSize := uint32(cols) << 2
+	baseAddr := uint32(builderHeaderSize + builderIndexSize + 1)
+	data := make([]byte, baseAddr-1+uint32(len(rows))*rowSize)
+
+	data[0] = uint8(b.t)
+	data[1] = cols
+	data[2] = uint8(b.date.Year() - 2000)
+	data[3] = uint8(b.date.Month())
+	data[4] = uint8(b.date.Day())
+	fileEndianness.PutUint32(data[5:], uint32(len(rows)))
+	fileEndianness.PutUint32(data[9:], baseAddr)
+	fileEndianness.PutUint32(data[21:], builderHeaderSize+1)
+
+	b.writeIndexes(data[builderHeaderSize:], rows)
+
+	pool := newStringPool(uint32(len(data)))
+	for i, r := range rows {
+		row := data[baseAddr-1+uint32(i)*rowSize:]
+		fileEndianness.PutUint32(row, r.from)
+		b.writeFields(row, r.res, pool)
+	}
+	return append(data, pool.buf.Bytes()...), nil
+}
+
+// WriteTo builds the db file and writes it to w
+func (b *Builder) WriteTo(w io.Writer) (int64, error) {
+	data, err := b.Bytes()
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// gets the columns count of the db type
+func (b *Builder) cols() uint8 {
+	cols := uint8(1)
+	for _, pos := range []uint8{countryPos[b.t], proxytypePos[b.t]} {
+		if pos > cols {
+			cols = pos
+		}
+	}
+	for _, f := range b.stringFields(&Result{}) {
+		if f.col > cols {
+			cols = f.col
+		}
+	}
+	return cols
+}
+
+// computes the db rows: records sorted, with non proxy rows filling the gaps, ending with the upper bound row
+func (b *Builder) rows() ([]builderRecord, error) {
+	records := make([]builderRecord, len(b.records))
+	copy(records, b.records)
+	sort.Slice(records, func(i, j int) bool { return records[i].from < records[j].from })
+
+	filler := &Result{Proxy: ProxyNOT}
+	rows := make([]builderRecord, 0, 2*len(records)+2)
+	if len(records) == 0 || records[0].from > 0 {
+		rows = append(rows, builderRecord{from: 0, res: filler})
+	}
+	for i, r := range records {
+		if i > 0 && r.from <= records[i-1].to {
+			return nil, fmt.Errorf(
+				"record %s-%s overlaps record %s-%s",
+				intToIPV4(r.from), intToIPV4(r.to), intToIPV4(records[i-1].from), intToIPV4(records[i-1].to),
+			)
+		}
+		rows = append(rows, r)
+		// the upper bound row already ends a record reaching the last addr
+		if r.to == math.MaxUint32 || i < len(records)-1 && records[i+1].from == r.to+1 {
+			continue
+		}
+		rows = append(rows, builderRecord{from: r.to + 1, res: filler})
+	}
+	return append(rows, builderRecord{from: math.MaxUint32, res: filler}), nil
+}
+
+// writes the index buckets: rows range holding the addrs of each bucket
+func (b *Builder) writeIndexes(data []byte, rows []builderRecord) {
+	last := uint32(len(rows) - 2)
+	row := uint32(0)
+	for i := uint32(0); i < maxIndexes; i++ {
+		low := i << 16
+		high := low | 0xFFFF
+		for row < last && rows[row+1].from <= low {
+			row++
+		}
+		start := row
+		end := row
+		for end < last && rows[end+1].from <= high {
+			end++
+		}
+		fileEndianness.PutUint32(data[i*8:], start)
+		fileEndianness.PutUint32(data[i*8+4:], end)
+	}
+}
+
+// a string field column
+type builderField struct {
+	col   uint8
+	value **string
+}
+
+// gets the string fields of res held by the db type, other than the country
+func (b *Builder) stringFields(res *Result) []builderField {
+	all := []builderField{
+		{regionPos[b.t], &res.Region},
+		{cityPos[b.t], &res.City},
+		{ispPos[b.t], &res.ISP},
+		{domainPos[b.t], &res.Domain},
+		{usageTypePos[b.t], &res.UsageType},
+		{asnPos[b.t], &res.ASN},
+		{asPos[b.t], &res.AS},
+		{lastSeenPos[b.t], &res.LastSeen},
+		{threatPos[b.t], &res.Threat},
+		{providerPos[b.t], &res.Provider},
+	}
+	fields := all[:0]
+	for _, f := range all {
+		if f.col != 0 {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// gets the strings of res stored by the db type
+func (b *Builder) storedStrings(res *Result) []string {
+	var strs []string
+	if countryPos[b.t] != 0 {
+		strs = append(strs, strOrDash(res.CountryCode), strOrDash(res.Country))
+	}
+	if proxytypePos[b.t] != 0 {
+		strs = append(strs, proxyName(res))
+	}
+	for _, f := range b.stringFields(res) {
+		strs = append(strs, strOrDash(*f.value))
+	}
+	return strs
+}
+
+// writes the fields columns of a row
+func (b *Builder) writeFields(row []byte, res *Result, pool *stringPool) {
+	if countryPos[b.t] != 0 {
+		addr := pool.addCountry(strOrDash(res.CountryCode), strOrDash(res.Country))
+		fileEndianness.PutUint32(row[(countryPos[b.t]-1)<<2:], addr)
+	}
+	if proxytypePos[b.t] != 0 {
+		fileEndianness.PutUint32(row[(proxytypePos[b.t]-1)<<2:], pool.add(proxyName(res)))
+	}
+	for _, f := range b.stringFields(res) {
+		fileEndianness.PutUint32(row[(f.col-1)<<2:], pool.add(strOrDash(*f.value)))
+	}
+}
+
+// gets the stored proxy type name of res: its raw proxy type when its proxy type is unknown, "-" for non proxies
+func proxyName(res *Result) string {
+	switch {
+	case res.Proxy == ProxyNA && res.RawProxy != nil:
+		return strOrDash(res.RawProxy)
+	case res.Proxy == ProxyNA || res.Proxy == ProxyNOT:
+		return "-"
+	}
+	return res.Proxy.String()
+}
+
+// gets a string value, or "-" when absent
+func strOrDash(s *string) string {
+	if s == nil || *s == "" {
+		return "-"
+	}
+	return *s
+}
+
+// deduplicated strings, stored after the rows
+type stringPool struct {
+	base  uint32
+	buf   bytes.Buffer
+	addrs map[string]uint32
+}
+
+// creates a string pool starting at base offset
+func newStringPool(base uint32) *stringPool {
+	return &stringPool{base: base, addrs: map[string]uint32{}}
+}
+
+// adds a string to the pool, returning its offset
+func (p *stringPool) add(s string) uint32 {
+	if addr, ok := p.addrs[s]; ok {
+		return addr
+	}
+	addr := p.write(s)
+	p.addrs[s] = addr
+	return addr
+}
+
+// adds a country to the pool: its code padded to 3 bytes, followed by its name, returning the code offset
+func (p *stringPool) addCountry(code, name string) uint32 {
+	key := code + "\x00" + name
+	if addr, ok := p.addrs[key]; ok {
+		return addr
+	}
+	addr := p.write(code)
+	for i := len(code); i < 2; i++ {
+		p.buf.WriteByte(' ')
+	}
+	p.write(name)
+	p.addrs[key] = addr
+	return addr
+}
+
+// writes a length prefixed string to the pool, returning its offset. Add rejects the strings longer than 255 bytes.
+func (p *stringPool) write(s string) uint32 {
+	addr := p.base + uint32(p.buf.Len())
+	p.buf.WriteByte(uint8(len(s)))
+	p.buf.WriteString(s)
+	return addr
+}
+
+// BuildFromCSV reads the records of a IP2Proxy csv file and writes the corresponding db file of type t to w.
+// The csv columns must match the db type, as in the IP2Proxy csv files:
+// ip_from, ip_to, proxy_type (PX2+), country_code, country_name, region_name (PX3+), city_name (PX3+), isp (PX4+),
+// domain (PX5+), usage_type (PX6+), asn (PX7+), as (PX7+), last_seen (PX8+), threat (PX9+), provider (PX11).
+func BuildFromCSV(r io.Reader, w io.Writer, t DbType, date time.Time) error {
+	b, err := NewBuilder(t, date)
+	if err != nil {
+		return err
+	}
+	reader := csv.NewReader(r)
+	// the country column holds two csv fields: its code and name
+	reader.FieldsPerRecord = int(b.cols()) + 2
+	for line := 1; ; line++ {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.Annotate(err, "cannot read csv")
+		}
+		if err := b.addCSVRecord(fields); err != nil {
+			return errors.Annotatef(err, "invalid csv record at line %d", line)
+		}
+	}
+	_, err = b.WriteTo(w)
+	return err
+}
+
+// adds a record from its csv fields
+func (b *Builder) addCSVRecord(fields []string) error {
+	from, err := parseCSVIP(fields[0])
+	if err != nil {
+		return err
+	}
+	to, err := parseCSVIP(fields[1])
+	if err != nil {
+		return err
+	}
+	res := &Result{}
+	values := map[uint8]**string{}
+	for _, f := range b.stringFields(res) {
+		values[f.col] = f.value
+	}
+	i := 2
+	for col := uint8(2); col <= b.cols(); col++ {
+		switch col {
+		case proxytypePos[b.t]:
+			res.Proxy = proxyNameToProxyType(fields[i])
+			res.RawProxy = &fields[i]
+		case countryPos[b.t]:
+			res.CountryCode = &fields[i]
+			i++
+			res.Country = &fields[i]
+		default:
+			value, ok := values[col]
+			if !ok {
+				return fmt.Errorf("no field for column %d of db type %d", col, b.t)
+			}
+			*value = &fields[i]
+		}
+		i++
+	}
+	return b.Add(from, to, res)
+}
+
+// parses a csv ip, either numeric or in dot notation
+func parseCSVIP(s string) (uint32, error) {
+	if n, err := strconv.ParseUint(s, 10, 32); err == nil {
+		return uint32(n), nil
+	}
+	ip := net.ParseIP(s)
+	if ip == nil || ip.To4() == nil {
+		return 0, fmt.Errorf("invalid IP %q", s)
+	}
+	return ipV4ToInt(ip)
+}
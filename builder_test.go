@@ -0,0 +1,311 @@
+package ip2proxy_test
+
+import (
+	"bytes"
+	"math"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/etf1/ip2proxy"
+)
+
+var _ = Describe("Builder", func() {
+	date := time.Date(2018, time.Month(3), 15, 0, 0, 0, 0, time.Local)
+
+	Context("when building from csv", func() {
+		csv := strings.Join([]string{
+			`"16777216","16777471","VPN","AU","Australia","Queensland","Brisbane","Some VPN"`,
+			`"16777472","16777472","TOR","CN","China","Fujian","Fuzhou","-"`,
+			`"134744064","134744319","DCH","US","United States","California","Mountain View","Google LLC"`,
+			`"4294967040","4294967295","PUB","FR","France","-","-","-"`,
+		}, "\n")
+		var db *DB
+		BeforeEach(func() {
+			var buf bytes.Buffer
+			Expect(BuildFromCSV(strings.NewReader(csv), &buf, PX4, date)).To(Succeed())
+			var err error
+			db, err = FromBytes(buf.Bytes())
+			Expect(err).To(BeNil())
+		})
+		It("should return the valid header infos", func() {
+			Expect(db.Type()).To(Equal(PX4))
+			Expect(db.Version()).To(Equal("PX4-2018-03-15"))
+			Expect(db.Count()).To(Equal(uint32(8)))
+		})
+		It("should build a valid db", func() {
+			Expect(db.Verify()).To(Succeed())
+		})
+		It("should return the records proxy types", func() {
+			list := map[string]ProxyType{
+				"0.0.0.0":         ProxyNOT,
+				"0.255.255.255":   ProxyNOT,
+				"1.0.0.0":         ProxyVPN,
+				"1.0.0.255":       ProxyVPN,
+				"1.0.1.0":         ProxyTOR,
+				"1.0.1.2":         ProxyNOT,
+				"8.8.7.255":       ProxyNOT,
+				"8.8.8.0":         ProxyDCH,
+				"8.8.8.255":       ProxyDCH,
+				"8.8.9.1":         ProxyNOT,
+				"255.255.254.255": ProxyNOT,
+				"255.255.255.1":   ProxyPUB,
+				"255.255.255.255": ProxyPUB,
+			}
+			for ip, expected := range list {
+				res, err := db.LookupIPV4Dot(ip)
+				Expect(err).To(BeNil())
+				Expect(res.Proxy).To(Equal(expected), ip)
+			}
+		})
+		It("should return the records fields", func() {
+			ptrStr := func(str string) *string { return &str }
+			source := &Source{Type: PX4, Version: "PX4-2018-03-15"}
+			res, err := db.LookupIPV4Dot("8.8.8.8")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal(&Result{
+				IP:          "8.8.8.8",
+				Country:     ptrStr("United States"),
+				CountryCode: ptrStr("US"),
+				Region:      ptrStr("California"),
+				City:        ptrStr("Mountain View"),
+				ISP:         ptrStr("Google LLC"),
+				Proxy:       ProxyDCH,
+				RawProxy:    ptrStr("DCH"),
+				Source:      source,
+			}))
+			res, err = db.LookupIPV4Dot("255.255.255.1")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal(&Result{
+				IP:          "255.255.255.1",
+				Country:     ptrStr("France"),
+				CountryCode: ptrStr("FR"),
+				Proxy:       ProxyPUB,
+				RawProxy:    ptrStr("PUB"),
+				Source:      source,
+			}))
+			res, err = db.LookupIPV4Dot("9.9.9.9")
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal(&Result{IP: "9.9.9.9", Proxy: ProxyNOT, RawProxy: ptrStr("-"), Source: source}))
+		})
+	})
+	Context("when building a db without proxy type", func() {
+		It("should return the records countries", func() {
+			b, err := NewBuilder(PX1, date)
+			Expect(err).To(BeNil())
+			code, name := "FR", "France"
+			Expect(b.Add(16777216, 16777471, &Result{CountryCode: &code, Country: &name})).To(Succeed())
+			data, err := b.Bytes()
+			Expect(err).To(BeNil())
+			db, err := FromBytes(data)
+			Expect(err).To(BeNil())
+			Expect(db.Verify()).To(Succeed())
+			list := map[string]*string{
+				"0.255.255.255": nil,
+				"1.0.0.0":       &name,
+				"1.0.0.255":     &name,
+				"1.0.1.1":       nil,
+			}
+			for ip, expected := range list {
+				res, err := db.LookupIPV4Dot(ip)
+				Expect(err).To(BeNil())
+				Expect(res.Country).To(Equal(expected), ip)
+			}
+		})
+	})
+	Context("when reading a db with another column layout", func() {
+		// builds a PX4 db, its city and isp columns being swapped
+		build := func() []byte {
+			csv := `"16777216","16777471","VPN","AU","Australia","Queensland","Brisbane","Some VPN"`
+			var buf bytes.Buffer
+			Expect(BuildFromCSV(strings.NewReader(csv), &buf, PX4, date)).To(Succeed())
+			data := buf.Bytes()
+			db, err := FromBytes(data)
+			Expect(err).To(BeNil())
+			for i := 0; i < int(db.Count()); i++ {
+				row := data[64+65536*8+i*db.RowSize():]
+				for j := 16; j < 20; j++ {
+					row[j], row[j+4] = row[j+4], row[j]
+				}
+			}
+			return data
+		}
+		It("should read the fields at their columns", func() {
+			db, err := FromBytes(build(), WithColumnLayout(map[Field]uint8{FieldCity: 6, FieldISP: 5}))
+			Expect(err).To(BeNil())
+			res, err := db.LookupIPV4Dot("1.0.0.1")
+			Expect(err).To(BeNil())
+			Expect(*res.City).To(Equal("Brisbane"))
+			Expect(*res.ISP).To(Equal("Some VPN"))
+			Expect(*res.Country).To(Equal("Australia"))
+		})
+		It("should return an error for an invalid layout", func() {
+			_, err := FromBytes(build(), WithColumnLayout(map[Field]uint8{FieldCity: 7}))
+			Expect(err).To(MatchError("invalid column layout: city column 7 out of the 6 columns"))
+		})
+	})
+	Context("when building records of unknown proxy types", func() {
+		It("should return their raw proxy type", func() {
+			csv := `"16777216","16777471","XYZ","AU","Australia"`
+			var buf bytes.Buffer
+			Expect(BuildFromCSV(strings.NewReader(csv), &buf, PX2, date)).To(Succeed())
+			db, err := FromBytes(buf.Bytes())
+			Expect(err).To(BeNil())
+			res, err := db.LookupIPV4Dot("1.0.0.1")
+			Expect(err).To(BeNil())
+			Expect(res.Proxy).To(Equal(ProxyNA))
+			Expect(*res.RawProxy).To(Equal("XYZ"))
+			values, err := db.Strings(FieldRawProxy)
+			Expect(err).To(BeNil())
+			Expect(values).To(Equal([]string{"-", "XYZ"}))
+
+			db, err = FromBytes(buf.Bytes(), WithStrictProxy())
+			Expect(err).To(BeNil())
+			res, err = db.LookupIPV4Dot("1.0.0.1")
+			Expect(res).To(BeNil())
+			Expect(err).To(MatchError(`1.0.0.1 proxy type "XYZ": unclassified proxy type`))
+			Expect(errors.Cause(err)).To(Equal(ErrUnclassified))
+			res, err = db.LookupIPV4Dot("1.0.1.1")
+			Expect(err).To(BeNil())
+			Expect(res.Proxy).To(Equal(ProxyNOT))
+		})
+	})
+	Context("when building a db with residential proxies", func() {
+		It("should return them as classified proxies", func() {
+			csv := `"16777216","16777471","RES","US","United States of America","California","Los Angeles","Home ISP",` +
+				`"home.example","ISP","64510","HOME-ISP","3","-","-"`
+			var buf bytes.Buffer
+			Expect(BuildFromCSV(strings.NewReader(csv), &buf, PX11, date)).To(Succeed())
+			db, err := FromBytes(buf.Bytes(), WithStrictProxy())
+			Expect(err).To(BeNil())
+			res, err := db.LookupIPV4Dot("1.0.0.1")
+			Expect(err).To(BeNil())
+			Expect(res.Proxy).To(Equal(ProxyRES))
+			Expect(*res.RawProxy).To(Equal("RES"))
+		})
+	})
+	Context("when building a db with search engine robots", func() {
+		It("should return them as search engines", func() {
+			b, err := NewBuilder(PX2, date)
+			Expect(err).To(BeNil())
+			Expect(b.Add(dotToInt("66.249.64.0"), dotToInt("66.249.95.255"), &Result{Proxy: ProxySES})).To(Succeed())
+			Expect(b.Add(dotToInt("66.249.96.0"), dotToInt("66.249.96.255"), &Result{Proxy: ProxyVPN})).To(Succeed())
+			data, err := b.Bytes()
+			Expect(err).To(BeNil())
+			db, err := FromBytes(data)
+			Expect(err).To(BeNil())
+			res, err := db.LookupIPV4Dot("66.249.66.1")
+			Expect(err).To(BeNil())
+			Expect(res.Proxy).To(Equal(ProxySES))
+			Expect(res.IsSearchEngine()).To(BeTrue())
+			for ip, expected := range map[string]bool{"66.249.66.1": true, "66.249.96.1": false, "1.1.1.1": false} {
+				ok, err := db.IsSearchEngine(net.ParseIP(ip))
+				Expect(err).To(BeNil())
+				Expect(ok).To(Equal(expected), ip)
+			}
+		})
+	})
+	Context("when building a db with data centers", func() {
+		It("should return their provider", func() {
+			ptrStr := func(str string) *string { return &str }
+			b, err := NewBuilder(PX11, date)
+			Expect(err).To(BeNil())
+			Expect(b.Add(dotToInt("3.0.0.0"), dotToInt("3.0.0.255"), &Result{Proxy: ProxyDCH, ISP: ptrStr("Amazon.com Inc."), Provider: ptrStr("AWS")})).To(Succeed())
+			Expect(b.Add(dotToInt("3.0.1.0"), dotToInt("3.0.1.255"), &Result{Proxy: ProxyDCH, ISP: ptrStr("Google LLC")})).To(Succeed())
+			Expect(b.Add(dotToInt("3.0.2.0"), dotToInt("3.0.2.255"), &Result{Proxy: ProxyDCH})).To(Succeed())
+			Expect(b.Add(dotToInt("3.0.3.0"), dotToInt("3.0.3.255"), &Result{Proxy: ProxyVPN, Provider: ptrStr("SampleVPN")})).To(Succeed())
+			data, err := b.Bytes()
+			Expect(err).To(BeNil())
+			db, err := FromBytes(data)
+			Expect(err).To(BeNil())
+			for ip, expected := range map[string]struct {
+				provider string
+				ok       bool
+			}{
+				"3.0.0.1": {"AWS", true},
+				"3.0.1.1": {"Google LLC", true},
+				"3.0.2.1": {"", true},
+				"3.0.3.1": {"", false},
+				"1.1.1.1": {"", false},
+			} {
+				provider, ok, err := db.Datacenter(net.ParseIP(ip))
+				Expect(err).To(BeNil())
+				Expect(provider).To(Equal(expected.provider), ip)
+				Expect(ok).To(Equal(expected.ok), ip)
+			}
+			_, _, err = db.Datacenter(nil)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+	Context("when building a db with 3 letters country codes", func() {
+		It("should return their long names", func() {
+			ptrStr := func(str string) *string { return &str }
+			b, err := NewBuilder(PX2, date)
+			Expect(err).To(BeNil())
+			Expect(b.Add(dotToInt("1.0.0.0"), dotToInt("1.0.0.255"), &Result{Proxy: ProxyVPN, CountryCode: ptrStr("CZE"), Country: ptrStr("Czechia")})).To(Succeed())
+			Expect(b.Add(dotToInt("1.0.1.0"), dotToInt("1.0.1.255"), &Result{Proxy: ProxyVPN, CountryCode: ptrStr("FR"), Country: ptrStr("France")})).To(Succeed())
+			Expect(b.Add(dotToInt("1.0.2.0"), dotToInt("1.0.2.255"), &Result{Proxy: ProxyVPN, Country: ptrStr("Unknown")})).To(Succeed())
+			data, err := b.Bytes()
+			Expect(err).To(BeNil())
+			db, err := FromBytes(data)
+			Expect(err).To(BeNil())
+			for ip, expected := range map[string][2]*string{
+				"1.0.0.1": {ptrStr("CZE"), ptrStr("Czechia")},
+				"1.0.1.1": {ptrStr("FR"), ptrStr("France")},
+				"1.0.2.1": {nil, ptrStr("Unknown")},
+			} {
+				res, err := db.LookupIPV4Dot(ip)
+				Expect(err).To(BeNil())
+				Expect(res.CountryCode).To(Equal(expected[0]), ip)
+				Expect(res.Country).To(Equal(expected[1]), ip)
+			}
+		})
+	})
+	Context("when building a record ending before the last addr", func() {
+		It("should leave the last addr out of it", func() {
+			b, err := NewBuilder(PX2, date)
+			Expect(err).To(BeNil())
+			Expect(b.Add(dotToInt("255.255.255.0"), dotToInt("255.255.255.254"), &Result{Proxy: ProxyVPN})).To(Succeed())
+			data, err := b.Bytes()
+			Expect(err).To(BeNil())
+			db, err := FromBytes(data)
+			Expect(err).To(BeNil())
+			Expect(db.Verify()).To(Succeed())
+			results, err := db.LookupAll(dotToInt("255.255.255.0"), math.MaxUint32)
+			Expect(err).To(BeNil())
+			Expect(results).To(HaveLen(2))
+			Expect(results[0].Proxy).To(Equal(ProxyVPN))
+			Expect(results[0].RangeTo).To(Equal(dotToInt("255.255.255.254")))
+			Expect(results[1].Proxy).To(Equal(ProxyNOT))
+			Expect(results[1].RangeFrom).To(Equal(uint32(math.MaxUint32)))
+		})
+	})
+	Context("when adding invalid records", func() {
+		It("should return an error", func() {
+			b, err := NewBuilder(PX2, date)
+			Expect(err).To(BeNil())
+			Expect(b.Add(2, 1, &Result{Proxy: ProxyVPN})).To(MatchError("invalid range 0.0.0.2-0.0.0.1"))
+			Expect(b.Add(1, 2, &Result{Proxy: ProxyNOT})).To(MatchError("record 0.0.0.1-0.0.0.2 is not a proxy, non proxy addrs must be left out"))
+			Expect(b.Add(1, 10, &Result{Proxy: ProxyVPN})).To(Succeed())
+			Expect(b.Add(5, 20, &Result{Proxy: ProxyVPN})).To(Succeed())
+			_, err = b.Bytes()
+			Expect(err).To(MatchError("record 0.0.0.5-0.0.0.20 overlaps record 0.0.0.1-0.0.0.10"))
+		})
+		It("should return an error for a string too long", func() {
+			b, err := NewBuilder(PX4, date)
+			Expect(err).To(BeNil())
+			isp := strings.Repeat("a", 256)
+			Expect(b.Add(1, 2, &Result{Proxy: ProxyVPN, ISP: &isp})).To(MatchError("record 0.0.0.1-0.0.0.2 holds a 256 bytes string, longer than 255 bytes"))
+			isp = isp[:255]
+			Expect(b.Add(1, 2, &Result{Proxy: ProxyVPN, ISP: &isp})).To(Succeed())
+		})
+		It("should return an error for an invalid csv", func() {
+			err := BuildFromCSV(strings.NewReader(`"1","lol","VPN","-","-"`), &bytes.Buffer{}, PX2, date)
+			Expect(err).To(MatchError(`invalid csv record at line 1: invalid IP "lol"`))
+		})
+	})
+})
@@ -0,0 +1,97 @@
+package ip2proxy
+
+import (
+	"runtime"
+	"sync"
+)
+
+// concurrency safe cache of decoded strings, indexed by their position in file
+type stringCache struct {
+	strs sync.Map
+	// decodings in progress, so that a string is decoded once by concurrent lookups
+	mu    sync.Mutex
+	calls map[uint32]*stringCall
+}
+
+// a string decoding in progress
+type stringCall struct {
+	done chan struct{}
+	s    string
+	err  error
+}
+
+// gets the cached string at pos, decoding and caching it if absent. Concurrent calls for the same absent string wait
+// for a single decoding and share its result.
+func (c *stringCache) getOrDecode(pos uint32, decode func() (string, error)) (string, error) {
+	if s, ok := c.get(pos); ok {
+		return s, nil
+	}
+	c.mu.Lock()
+	if call, ok := c.calls[pos]; ok {
+		c.mu.Unlock()
+		<-call.done
+		return call.s, call.err
+	}
+	if c.calls == nil {
+		c.calls = map[uint32]*stringCall{}
+	}
+	call := &stringCall{done: make(chan struct{})}
+	c.calls[pos] = call
+	c.mu.Unlock()
+
+	call.s, call.err = decode()
+	if call.err == nil {
+		call.s = c.set(pos, call.s)
+	}
+	c.mu.Lock()
+	delete(c.calls, pos)
+	c.mu.Unlock()
+	close(call.done)
+	return call.s, call.err
+}
+
+// gets a cached string
+func (c *stringCache) get(pos uint32) (string, bool) {
+	s, ok := c.strs.Load(pos)
+	if !ok {
+		return "", false
+	}
+	return s.(string), true
+}
+
+// caches a string, returning the already cached one if any
+func (c *stringCache) set(pos uint32, s string) string {
+	actual, _ := c.strs.LoadOrStore(pos, s)
+	return actual.(string)
+}
+
+// decodes every record so their strings get cached, splitting rows between GOMAXPROCS goroutines
+func (db *DB) prewarm() error {
+	rows := db.header.Count - 1
+	workers := uint32(runtime.GOMAXPROCS(0))
+	if workers > rows {
+		workers = rows
+	}
+	chunk := (rows + workers - 1) / workers
+	errs := make(chan error, workers)
+	var wg sync.WaitGroup
+	for from := uint32(0); from < rows; from += chunk {
+		to := from + chunk
+		if to > rows {
+			to = rows
+		}
+		wg.Add(1)
+		go func(from, to uint32) {
+			defer wg.Done()
+			for i := from; i < to; i++ {
+				if _, err := db.readIPV4Record(recordOffset(db.ipv4(), i)); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(from, to)
+	}
+	wg.Wait()
+	close(errs)
+	return <-errs
+}
@@ -0,0 +1,158 @@
+package ip2proxy_test
+
+import (
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/etf1/ip2proxy"
+)
+
+var _ = Describe("Cache", func() {
+	Context("when prewarmed", func() {
+		plain, err := Open(filepath.Join("testdata", "IP2PROXY-LITE-PX4.BIN"))
+		if err != nil {
+			Fail("Loading IP2PROXY-LITE-PX4.BIN should not have failed", 1)
+		}
+		cached, err := Open(filepath.Join("testdata", "IP2PROXY-LITE-PX4.BIN"), WithPrewarm())
+		if err != nil {
+			Fail("Loading IP2PROXY-LITE-PX4.BIN with prewarm should not have failed", 1)
+		}
+		It("should return the same results as an uncached db", func() {
+			for _, ip := range []string{"78.220.10.108", "8.8.8.8", "217.212.231.208", "2.6.120.66", "212.9.226.39"} {
+				expected, err := plain.LookupIPV4Dot(ip)
+				Expect(err).To(BeNil())
+				res, err := cached.LookupIPV4Dot(ip)
+				Expect(err).To(BeNil())
+				Expect(res).To(Equal(expected))
+			}
+		})
+	})
+	Context("when looked up concurrently", func() {
+		It("should return the same results as an uncached db", func() {
+			plain, err := Open(filepath.Join("testdata", "PX11-SAMPLE.BIN"))
+			Expect(err).To(BeNil())
+			expected, err := plain.LookupIPV4Dot("1.0.0.1")
+			Expect(err).To(BeNil())
+			cached, err := Open(filepath.Join("testdata", "PX11-SAMPLE.BIN"), WithStringCache())
+			Expect(err).To(BeNil())
+			results := make(chan *Result)
+			for i := 0; i < 16; i++ {
+				go func() {
+					defer GinkgoRecover()
+					res, err := cached.LookupIPV4Dot("1.0.0.1")
+					Expect(err).To(BeNil())
+					results <- res
+				}()
+			}
+			for i := 0; i < 16; i++ {
+				Expect(<-results).To(Equal(expected))
+			}
+		})
+	})
+	Context("with a shared interner", func() {
+		It("should hold the strings of all the dbs once", func() {
+			in := NewInterner()
+			var dbs []*DB
+			for i := 0; i < 2; i++ {
+				db, err := Open(filepath.Join("testdata", "PX11-SAMPLE.BIN"), WithInterner(in), WithPrewarm())
+				Expect(err).To(BeNil())
+				dbs = append(dbs, db)
+			}
+			count := in.Len()
+			Expect(count).To(BeNumerically(">", 0))
+			for _, db := range dbs {
+				res, err := db.LookupIPV4Dot("1.0.0.1")
+				Expect(err).To(BeNil())
+				Expect(*res.ISP).To(Equal("Sample VPN Ltd"))
+			}
+			Expect(in.Len()).To(Equal(count))
+		})
+	})
+	Context("with a range cache", func() {
+		var plain *DB
+		BeforeEach(func() {
+			var err error
+			plain, err = Open(filepath.Join("testdata", "PX11-SAMPLE.BIN"))
+			Expect(err).To(BeNil())
+		})
+		It("should serve the addrs of a record range from the cache", func() {
+			db, err := Open(filepath.Join("testdata", "PX11-SAMPLE.BIN"), WithRangeCache(2, 0))
+			Expect(err).To(BeNil())
+			for _, ip := range []string{"1.0.0.1", "1.0.0.2", "1.0.0.255", "1.0.1.0", "1.0.0.3", "9.9.9.9", "1.0.1.1"} {
+				expected, err := plain.LookupIPV4Dot(ip)
+				Expect(err).To(BeNil())
+				res, err := db.LookupIPV4Dot(ip)
+				Expect(err).To(BeNil())
+				Expect(res).To(Equal(expected))
+			}
+			Expect(db.RangeCacheStats()).To(Equal(RangeCacheStats{Hits: 3, Misses: 4, Evictions: 2, Len: 2}))
+		})
+		It("should not share the cached results", func() {
+			db, err := Open(filepath.Join("testdata", "PX11-SAMPLE.BIN"), WithRangeCache(10, 0))
+			Expect(err).To(BeNil())
+			res, err := db.LookupIPV4Dot("1.0.0.1")
+			Expect(err).To(BeNil())
+			*res.ISP = "changed"
+			res, err = db.LookupIPV4Dot("1.0.0.2")
+			Expect(err).To(BeNil())
+			Expect(*res.ISP).To(Equal("Sample VPN Ltd"))
+			Expect(db.RangeCacheStats().Hits).To(Equal(uint64(1)))
+		})
+		It("should expire the cached records", func() {
+			db, err := Open(filepath.Join("testdata", "PX11-SAMPLE.BIN"), WithRangeCache(10, time.Millisecond))
+			Expect(err).To(BeNil())
+			_, err = db.LookupIPV4Dot("1.0.0.1")
+			Expect(err).To(BeNil())
+			time.Sleep(2 * time.Millisecond)
+			_, err = db.LookupIPV4Dot("1.0.0.2")
+			Expect(err).To(BeNil())
+			Expect(db.RangeCacheStats()).To(Equal(RangeCacheStats{Misses: 2, Len: 1}))
+		})
+		It("should serve the addrs not detected as proxies from a cached gap", func() {
+			db, err := Open(filepath.Join("testdata", "PX11-SAMPLE.BIN"), WithRangeCache(10, 0))
+			Expect(err).To(BeNil())
+			for _, ip := range []string{"9.9.9.9", "200.1.2.3", "255.255.254.255", "4.0.0.1", "255.255.255.0"} {
+				expected, err := plain.LookupIPV4Dot(ip)
+				Expect(err).To(BeNil())
+				res, err := db.LookupIPV4Dot(ip)
+				Expect(err).To(BeNil())
+				Expect(res).To(Equal(expected))
+			}
+			Expect(db.RangeCacheStats()).To(Equal(RangeCacheStats{Hits: 2, Misses: 3, Len: 2}))
+		})
+		It("should expire the cached gaps", func() {
+			db, err := Open(filepath.Join("testdata", "PX11-SAMPLE.BIN"), WithRangeCache(10, time.Millisecond))
+			Expect(err).To(BeNil())
+			_, err = db.LookupIPV4Dot("9.9.9.9")
+			Expect(err).To(BeNil())
+			time.Sleep(2 * time.Millisecond)
+			_, err = db.LookupIPV4Dot("9.9.9.10")
+			Expect(err).To(BeNil())
+			Expect(db.RangeCacheStats()).To(Equal(RangeCacheStats{Misses: 2, Len: 1}))
+		})
+		It("should answer the lookups as without cache", func() {
+			lite, err := Open(filepath.Join("testdata", "IP2PROXY-LITE-PX4.BIN"))
+			Expect(err).To(BeNil())
+			db, err := Open(filepath.Join("testdata", "IP2PROXY-LITE-PX4.BIN"), WithRangeCache(64, 0))
+			Expect(err).To(BeNil())
+			for i := 0; i < 2; i++ {
+				for ip := uint32(16777216); ip < 16843008; ip += 61 {
+					expected, err := lite.LookupIPV4Num(ip)
+					Expect(err).To(BeNil())
+					res, err := db.LookupIPV4Num(ip)
+					Expect(err).To(BeNil())
+					Expect(res).To(Equal(expected))
+				}
+			}
+			Expect(db.RangeCacheStats().Hits).NotTo(BeZero())
+		})
+		It("should have no stats without cache", func() {
+			_, err := plain.LookupIPV4Dot("1.0.0.1")
+			Expect(err).To(BeNil())
+			Expect(plain.RangeCacheStats()).To(BeZero())
+		})
+	})
+})
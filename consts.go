@@ -1,6 +1,9 @@
 package ip2proxy
 
-import "encoding/binary"
+import (
+	"encoding/binary"
+	"strings"
+)
 
 // DbType is the type of db
 type DbType uint8
@@ -16,6 +19,22 @@ const (
 	PX3 DbType = 3
 	// PX4 is the P2Proxy IP-PROXYTYPE-COUNTRY-REGION-CITY-ISP database
 	PX4 DbType = 4
+	// PX5 is the IP2Proxy IP-PROXYTYPE-COUNTRY-REGION-CITY-ISP-DOMAIN database
+	PX5 DbType = 5
+	// PX6 is the IP2Proxy IP-PROXYTYPE-COUNTRY-REGION-CITY-ISP-DOMAIN-USAGETYPE database
+	PX6 DbType = 6
+	// PX7 is the IP2Proxy IP-PROXYTYPE-COUNTRY-REGION-CITY-ISP-DOMAIN-USAGETYPE-ASN database
+	PX7 DbType = 7
+	// PX8 is the IP2Proxy IP-PROXYTYPE-COUNTRY-REGION-CITY-ISP-DOMAIN-USAGETYPE-ASN-LASTSEEN database
+	PX8 DbType = 8
+	// PX9 is the IP2Proxy IP-PROXYTYPE-COUNTRY-REGION-CITY-ISP-DOMAIN-USAGETYPE-ASN-LASTSEEN-THREAT database
+	PX9 DbType = 9
+	// PX10 is the IP2Proxy IP-PROXYTYPE-COUNTRY-REGION-CITY-ISP-DOMAIN-USAGETYPE-ASN-LASTSEEN-THREAT-RESIDENTIAL
+	// database
+	PX10 DbType = 10
+	// PX11 is the IP2Proxy IP-PROXYTYPE-COUNTRY-REGION-CITY-ISP-DOMAIN-USAGETYPE-ASN-LASTSEEN-THREAT-RESIDENTIAL-PROVIDER
+	// database
+	PX11 DbType = 11
 )
 
 // ProxyType is the type of proxy detected
@@ -41,8 +60,122 @@ const (
 	// ProxyWEB are Web Proxies. These are web services which make web requests on a user's behalf.
 	// These differ from VPNs or Public Proxies in that they are simple web-based proxies rather than operating at the IP address and other ports level.
 	ProxyWEB
+	// ProxySES are Search Engine Robots. These are services which perform crawling or scraping of websites, such as
+	// the search engine spiders.
+	ProxySES
+	// ProxyRES are Residential Proxies. These services offer users proxy connections through residential ISP with or
+	// without consents of peers to share their idle resources. They are only reported by PX10 and PX11 dbs.
+	ProxyRES
 )
 
+// String returns the proxy type name
+func (p ProxyType) String() string {
+	switch p {
+	case ProxyNOT:
+		return "NOT"
+	case ProxyVPN:
+		return "VPN"
+	case ProxyTOR:
+		return "TOR"
+	case ProxyDCH:
+		return "DCH"
+	case ProxyPUB:
+		return "PUB"
+	case ProxyWEB:
+		return "WEB"
+	case ProxySES:
+		return "SES"
+	case ProxyRES:
+		return "RES"
+	default:
+		return "N/A"
+	}
+}
+
+// Description returns a human readable description of the proxy type
+func (p ProxyType) Description() string {
+	switch p {
+	case ProxyNOT:
+		return "Not a proxy"
+	case ProxyVPN:
+		return "Anonymizing VPN service"
+	case ProxyTOR:
+		return "Tor exit node"
+	case ProxyDCH:
+		return "Hosting provider, data center or content delivery network"
+	case ProxyPUB:
+		return "Public proxy"
+	case ProxyWEB:
+		return "Web proxy"
+	case ProxySES:
+		return "Search engine robot"
+	case ProxyRES:
+		return "Residential proxy"
+	default:
+		return "Not available"
+	}
+}
+
+// RiskScoreOverrides overrides the default risk scores of the proxy types returned by RiskScore. It must be set before
+// any call to RiskScore, e.g. at init, not being safe for concurrent changes.
+var RiskScoreOverrides = map[ProxyType]int{}
+
+// default risk scores of the proxy types, see RiskScore
+var riskScores = map[ProxyType]int{
+	ProxyNA:  0,
+	ProxyNOT: 0,
+	ProxySES: 10,
+	ProxyDCH: 50,
+	ProxyPUB: 75,
+	ProxyWEB: 75,
+	ProxyRES: 75,
+	ProxyVPN: 90,
+	ProxyTOR: 100,
+}
+
+// RiskScore returns the risk weight of the proxy type, from 0 to 100, for a weighted fraud score. The defaults are:
+//   - NOT and N/A: 0
+//   - SES: 10
+//   - DCH: 50
+//   - PUB, WEB and RES: 75
+//   - VPN: 90
+//   - TOR: 100
+//
+// A score set in RiskScoreOverrides takes precedence over the default one.
+func (p ProxyType) RiskScore() int {
+	if score, ok := RiskScoreOverrides[p]; ok {
+		return score
+	}
+	return riskScores[p]
+}
+
+// ProxyTypeFromDescription gets the proxy type of a description returned by Description, ignoring case.
+// ok is false for an unknown description.
+func ProxyTypeFromDescription(s string) (p ProxyType, ok bool) {
+	for t := ProxyNA; t <= ProxyRES; t++ {
+		if strings.EqualFold(t.Description(), s) {
+			return t, true
+		}
+	}
+	return ProxyNA, false
+}
+
+// MarshalText encodes the proxy type as its name
+func (p ProxyType) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText decodes a proxy type name, unknown names being decoded as ProxyNA
+func (p *ProxyType) UnmarshalText(text []byte) error {
+	*p = ProxyNA
+	for t := ProxyNOT; t <= ProxyRES; t++ {
+		if t.String() == string(text) {
+			*p = t
+		}
+	}
+	return nil
+}
+
 // get proxy type according to name
 func proxyNameToProxyType(name string) ProxyType {
 	switch name {
@@ -58,17 +191,28 @@ func proxyNameToProxyType(name string) ProxyType {
 		return ProxyPUB
 	case "WEB":
 		return ProxyWEB
+	case "SES":
+		return ProxySES
+	case "RES":
+		return ProxyRES
 	default:
 		return ProxyNA
 	}
 }
 
 // Fields indexes.
-var countryPos = []uint8{0, 2, 3, 3, 3}
-var regionPos = []uint8{0, 0, 0, 4, 4}
-var cityPos = []uint8{0, 0, 0, 5, 5}
-var ispPos = []uint8{0, 0, 0, 0, 6}
-var proxytypePos = []uint8{0, 0, 2, 2, 2}
+var countryPos = []uint8{0, 2, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3}
+var regionPos = []uint8{0, 0, 0, 4, 4, 4, 4, 4, 4, 4, 4, 4}
+var cityPos = []uint8{0, 0, 0, 5, 5, 5, 5, 5, 5, 5, 5, 5}
+var ispPos = []uint8{0, 0, 0, 0, 6, 6, 6, 6, 6, 6, 6, 6}
+var proxytypePos = []uint8{0, 0, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2}
+var domainPos = []uint8{0, 0, 0, 0, 0, 7, 7, 7, 7, 7, 7, 7}
+var usageTypePos = []uint8{0, 0, 0, 0, 0, 0, 8, 8, 8, 8, 8, 8}
+var asnPos = []uint8{0, 0, 0, 0, 0, 0, 0, 9, 9, 9, 9, 9}
+var asPos = []uint8{0, 0, 0, 0, 0, 0, 0, 10, 10, 10, 10, 10}
+var lastSeenPos = []uint8{0, 0, 0, 0, 0, 0, 0, 0, 11, 11, 11, 11}
+var threatPos = []uint8{0, 0, 0, 0, 0, 0, 0, 0, 0, 12, 12, 12}
+var providerPos = []uint8{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 13}
 
 // File endianness
 var fileEndianness = binary.LittleEndian
@@ -1,11 +1,18 @@
 package ip2proxy
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
 	"encoding/binary"
+	"encoding/hex"
 	"fmt"
 	"io"
-	"io/ioutil"
+	"math"
+	"math/big"
 	"net"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/juju/errors"
@@ -13,22 +20,66 @@ import (
 
 // DB holds a parsed database instance
 type DB struct {
-	data        []byte
-	dataSize    uint32
-	header      *dbHeader
-	positions   *positions
-	ipv4Indexes [maxIndexes][2]uint32
+	data            []byte
+	dataSize        uint32
+	header          *dbHeader
+	positions       *positions
+	ipv4Indexes     [maxIndexes][2]uint32
+	ipv6Indexes     [maxIndexes][2]uint32
+	bounds          [2]uint32
+	opts            options
+	source          Source
+	strings         *stringCache
+	ranges          *rangeCache
+	lazyIndexes     *lazyIndexes
+	lazyIPv6Indexes *lazyIndexes
+	unmap           func() error
+	// unknown raw proxy types already warned about
+	unknownProxies sync.Map
+	// only the header was read, see OpenHeaderOnly
+	headerOnly bool
+	fileSize   uint32
 }
 
-// Result holds the lookup results
+// index buckets state when lazily loaded
+type lazyIndexes struct {
+	once [maxIndexes]sync.Once
+	errs [maxIndexes]error
+}
+
+// Result holds the lookup results.
+// Reserved is set by the lookups of a single addr when it is a reserved one, see IsReserved, for a db opened
+// WithReserved or WithReservedFlag.
 type Result struct {
-	IP          string
-	Country     *string
-	CountryCode *string
-	City        *string
-	ISP         *string
-	Region      *string
-	Proxy       ProxyType
+	IP           string    `json:"ip"`
+	Country      *string   `json:"country,omitempty"`
+	CountryCode  *string   `json:"country_code,omitempty"`
+	City         *string   `json:"city,omitempty"`
+	ISP          *string   `json:"isp,omitempty"`
+	Region       *string   `json:"region,omitempty"`
+	Proxy        ProxyType `json:"proxy"`
+	Domain       *string   `json:"domain,omitempty"`
+	UsageType    *string   `json:"usage_type,omitempty"`
+	ASN          *string   `json:"asn,omitempty"`
+	AS           *string   `json:"as,omitempty"`
+	LastSeen     *string   `json:"last_seen,omitempty"`
+	Threat       *string   `json:"threat,omitempty"`
+	Provider     *string   `json:"provider,omitempty"`
+	RawProxy     *string   `json:"raw_proxy,omitempty"`
+	CountryRaw   *string   `json:"country_raw,omitempty"`
+	ISPCanonical *string   `json:"isp_canonical,omitempty"`
+	RangeFrom    uint32    `json:"range_from,omitempty"`
+	RangeTo      uint32    `json:"range_to,omitempty"`
+	Source       *Source   `json:"source,omitempty"`
+	Reserved     bool      `json:"reserved,omitempty"`
+	// storage of the strings when pooled
+	arena *resultArena
+}
+
+// Source identifies the db a result was read from
+type Source struct {
+	Type    DbType `json:"type"`
+	Version string `json:"version"`
 }
 
 // Database header
@@ -42,48 +93,108 @@ type dbHeader struct {
 	Month          uint8
 	Day            uint8
 	IPv4ColumnSize uint8
+	ProductCode    uint8
+	LicenseCode    uint8
+	IPv6Count      uint32
+	IPv6BaseAddr   uint32
+	IPv6IndexAddr  uint32
+	IPv6ColumnSize uint16
 }
 
 // fields positions according to db type
 type positions struct {
-	Country uint8
-	Region  uint8
-	City    uint8
-	ISP     uint8
-	Proxy   uint8
+	Country   uint8
+	Region    uint8
+	City      uint8
+	ISP       uint8
+	Proxy     uint8
+	Domain    uint8
+	UsageType uint8
+	ASN       uint8
+	AS        uint8
+	LastSeen  uint8
+	Threat    uint8
+	Provider  uint8
 }
 
 // Open will opens a db file and parses it
-func Open(path string) (*DB, error) {
-	data, err := ioutil.ReadFile(path)
-	if err != nil || len(data) == 0 {
-		if err == nil {
-			err = fmt.Errorf("%s is empty or not redable", path)
-		}
-		return nil, errors.Annotate(err, "cannot open/read db file")
-	}
-	return FromBytes(data)
+func Open(path string, opts ...Option) (*DB, error) {
+	return OpenSource(FileSource(path), opts...)
+}
+
+// OpenReader reads a db file from r and parses it
+func OpenReader(r io.Reader, opts ...Option) (*DB, error) {
+	return OpenSource(ReaderSource(r), opts...)
 }
 
 // FromBytes takes a byte slice corresponding to a IP2Proxy file and returns the parsed DB object.
-func FromBytes(data []byte) (*DB, error) {
+func FromBytes(data []byte, opts ...Option) (*DB, error) {
 	if len(data) < 1024 {
 		return nil, fmt.Errorf("byte slice is empty or too small")
 	}
+	if uint64(len(data)) > math.MaxUint32 {
+		return nil, fmt.Errorf("byte slice of %d bytes beyond the 4GB addressable by the db offsets", len(data))
+	}
 	db := &DB{
 		data:     data,
 		dataSize: uint32(len(data)),
+		opts:     options{maxResults: DefaultMaxResults, logger: nopLogger{}},
+	}
+	for _, opt := range opts {
+		opt(&db.opts)
+	}
+	if err := db.checkSHA256(); err != nil {
+		return nil, err
 	}
 	if err := db.readHeader(); err != nil {
 		return nil, errors.Annotate(err, "cannot read db header")
 	}
-	db.computePositions()
-	if err := db.readIPv4Indexes(); err != nil {
-		return nil, errors.Annotate(err, "cannot read db index")
+	db.source = Source{Type: db.Type(), Version: db.Version()}
+	db.warnHeader()
+	if db.opts.maxAge > 0 && time.Since(db.Date()) > db.opts.maxAge {
+		return nil, errors.Annotatef(ErrExpired, "%s older than %s", db.Version(), db.opts.maxAge)
+	}
+	if err := db.computePositions(); err != nil {
+		return nil, errors.Annotate(err, "invalid column layout")
+	}
+	if err := db.readIndexes(); err != nil {
+		return nil, err
+	}
+	from, to, err := db.readIPv4Bounds()
+	if err != nil {
+		return nil, err
+	}
+	db.bounds = [2]uint32{from, to}
+	if db.opts.stringCache {
+		db.strings = &stringCache{}
+	}
+	if db.opts.rangeCacheSize > 0 {
+		db.ranges = newRangeCache(db.opts.rangeCacheSize, db.opts.rangeCacheTTL)
+	}
+	if db.opts.prewarm {
+		if err := db.prewarm(); err != nil {
+			return nil, errors.Annotate(err, "cannot prewarm db")
+		}
 	}
 	return db, nil
 }
 
+// checks the digest of the db file when an expected one is set
+func (db *DB) checkSHA256() error {
+	if db.opts.expectedSHA256 == "" {
+		return nil
+	}
+	expected, err := hex.DecodeString(db.opts.expectedSHA256)
+	if err != nil || len(expected) != sha256.Size {
+		return fmt.Errorf("invalid expected sha256 %q", db.opts.expectedSHA256)
+	}
+	sum := sha256.Sum256(db.data)
+	if !bytes.Equal(sum[:], expected) {
+		return errors.Annotatef(ErrChecksumMismatch, "sha256 %x, expected %x", sum, expected)
+	}
+	return nil
+}
+
 // Type gets the db type id
 func (db *DB) Type() DbType {
 	return db.header.Type
@@ -91,25 +202,50 @@ func (db *DB) Type() DbType {
 
 // TypeName gets the db type name
 func (db *DB) TypeName() string {
-	switch db.header.Type {
-	case PX1:
-		return "PX1"
-	case PX2:
-		return "PX2"
-	case PX3:
-		return "PX3"
-	case PX4:
-		return "PX4"
-	default:
+	if db.header.Type < PX1 || db.header.Type > PX11 {
 		return "N/A"
 	}
+	return fmt.Sprintf("PX%d", db.header.Type)
 }
 
-// Count returns the number of records in database
+// Count returns the number of records rows in database, as stored in its header. It includes the last row, which only
+// holds the end of the previous record, and the rows holding no addr, starting where the next row starts, which
+// Iterate skips: see EffectiveCount for the number of records actually holding addrs.
 func (db *DB) Count() uint32 {
 	return db.header.Count
 }
 
+// Columns returns the number of columns of each ipv4 record, the first one holding the record first addr
+func (db *DB) Columns() int {
+	return int(db.header.Cols)
+}
+
+// RowSize returns the size in bytes of each ipv4 record
+func (db *DB) RowSize() int {
+	return int(db.header.IPv4ColumnSize)
+}
+
+// ProductCode returns the product code of the db header, 2 for IP2Proxy files, or 0 when the file doesn't carry it
+func (db *DB) ProductCode() uint8 {
+	return db.header.ProductCode
+}
+
+// LicenseCode returns the license code of the db header, telling apart the LITE and commercial editions of a
+// product, or 0 when the file doesn't carry it
+func (db *DB) LicenseCode() uint8 {
+	return db.header.LicenseCode
+}
+
+// HasIPv6 checks if the db holds ipv6 records
+func (db *DB) HasIPv6() bool {
+	return db.header.IPv6Count > 0
+}
+
+// IPv6Count returns the number of ipv6 records in database
+func (db *DB) IPv6Count() uint32 {
+	return db.header.IPv6Count
+}
+
 // Date returns the date of the current db version
 func (db *DB) Date() time.Time {
 	return time.Date(
@@ -138,6 +274,16 @@ func (db *DB) LookupIPV4(ip net.IP) (*Result, error) {
 	return db.lookupIPV4(ipnum)
 }
 
+// lookups a net.IP ipv4 addr in database as LookupIPV4 does, an ipv6 addr returning ErrInvalidIP rather than being
+// looked up by its low 32 bits
+func (db *DB) lookupNetIPV4(ip net.IP) (*Result, error) {
+	ipnum, err := netIPV4ToInt(ip)
+	if err != nil {
+		return nil, err
+	}
+	return db.lookupIPV4(ipnum)
+}
+
 // LookupIPV4Dot lookups a dot notation (1.2.3.4) ipv4 address in database
 func (db *DB) LookupIPV4Dot(ip string) (*Result, error) {
 	ipnum, err := ipV4Dot2int(ip)
@@ -152,6 +298,155 @@ func (db *DB) LookupIPV4Num(ip uint32) (*Result, error) {
 	return db.lookupIPV4(ip)
 }
 
+// Lookup lookups a numeric addr of any family in database: values up to 0xFFFFFFFF are ipv4 addrs, larger ones ipv6
+// addrs. The ipv6 addrs from ::0 to ::FFFF:FFFF can not be told apart from ipv4 ones and are looked up as such. As
+// ipv6 lookups are not supported yet, ipv6 addrs return an error, as well as negative values and values beyond 128
+// bits, their cause being ErrInvalidIP.
+func (db *DB) Lookup(num *big.Int) (*Result, error) {
+	if num == nil || num.Sign() < 0 || num.BitLen() > 128 {
+		return nil, errors.Annotatef(ErrInvalidIP, "%s is out of the addrs range", num)
+	}
+	if num.BitLen() > 32 {
+		return nil, errors.Annotatef(ErrInvalidIP, "%s is not an ipv4 addr", num)
+	}
+	return db.lookupIPV4(uint32(num.Uint64()))
+}
+
+// LookupTimed lookups a net.IP ipv4 address in database as LookupIPV4 does, also returning the duration of the lookup,
+// from the search of its record to its decoding. An ipv6 addr returns ErrInvalidIP.
+func (db *DB) LookupTimed(ip net.IP) (*Result, time.Duration, error) {
+	start := time.Now()
+	res, err := db.lookupNetIPV4(ip)
+	return res, time.Since(start), err
+}
+
+// LookupFlat lookups a net.IP ipv4 address in database, returning the result as plain values.
+// found is false when the address is not in database. An ipv6 addr returns ErrInvalidIP.
+func (db *DB) LookupFlat(ip net.IP) (res FlatResult, found bool, err error) {
+	r, err := db.lookupNetIPV4(ip)
+	if err != nil || r == nil {
+		return FlatResult{}, false, err
+	}
+	return r.Flat(), true, nil
+}
+
+// IsSearchEngine lookups a net.IP ipv4 address in database, checking if it is a search engine robot. An address not
+// in database is not one, and an ipv6 addr returns ErrInvalidIP.
+func (db *DB) IsSearchEngine(ip net.IP) (bool, error) {
+	res, err := db.lookupNetIPV4(ip)
+	if err != nil || res == nil {
+		return false, err
+	}
+	return res.IsSearchEngine(), nil
+}
+
+// Datacenter lookups a net.IP ipv4 address in database, checking if it is a hosting provider, data center or CDN one
+// (DCH): ok is then true and provider is the name of its provider, or of its ISP when the record has no provider. Only
+// the proxy type, provider and ISP fields of the record are read. An address not in database is not a DCH one, and
+// an ipv6 addr returns ErrInvalidIP.
+func (db *DB) Datacenter(ip net.IP) (provider string, ok bool, err error) {
+	off, err := db.findRecordIPV4(ip)
+	if err != nil || off == 0 || db.positions.Proxy == 0 {
+		return "", false, err
+	}
+	res := &Result{}
+	if err := db.readRecordProxy(res, off); err != nil || res.Proxy != ProxyDCH {
+		return "", false, err
+	}
+	for _, f := range []struct {
+		pos  uint8
+		name string
+	}{{db.positions.Provider, "provider"}, {db.positions.ISP, "isp"}} {
+		if f.pos == 0 {
+			continue
+		}
+		v, err := db.readRecordStr(res, off, f.pos, f.name)
+		if err != nil {
+			return "", false, err
+		}
+		if v != nil {
+			return *v, true, nil
+		}
+	}
+	return "", true, nil
+}
+
+// InCountry lookups a net.IP ipv4 address in database, checking if its country code is code, ignoring case. Only the
+// country field of the record is read. An address not in database is in no country, and an ipv6 addr returns
+// ErrInvalidIP.
+func (db *DB) InCountry(ip net.IP, code string) (bool, error) {
+	off, err := db.findRecordIPV4(ip)
+	if err != nil || off == 0 || db.positions.Country == 0 {
+		return false, err
+	}
+	pos, err := db.readUint32(off + uint32(db.positions.Country))
+	if err != nil {
+		return false, err
+	}
+	short, err := db.readResultStr(&Result{}, pos, "country short name")
+	if err != nil {
+		return false, err
+	}
+	return short != "" && short != "-" && strings.EqualFold(short, code), nil
+}
+
+// gets the byte offset of the record holding the ipv4 addr ip, 0 when not found, for the lookups only reading some
+// fields of the record. Reserved addrs are looked up as the other ones.
+func (db *DB) findRecordIPV4(ip net.IP) (uint32, error) {
+	if err := db.checkRecords(); err != nil {
+		return 0, err
+	}
+	ipnum, err := netIPV4ToInt(ip)
+	if err != nil {
+		return 0, err
+	}
+	if ipnum < db.bounds[0] || ipnum > db.bounds[1] {
+		return 0, nil
+	}
+	pos, err := db.findPosForIPV4(ipnum)
+	if err != nil || pos == 0 {
+		return 0, err
+	}
+	return pos, nil
+}
+
+// CompareIPs lookups the ipv4 addrs a and b in database, returning their values for each field which values differ,
+// as Result.Diff does. An addr not found in database holds no field, and an ipv6 addr returns ErrInvalidIP.
+func (db *DB) CompareIPs(a, b net.IP) (map[Field][2]*string, error) {
+	resA, err := db.lookupNetIPV4(a)
+	if err != nil {
+		return nil, err
+	}
+	resB, err := db.lookupNetIPV4(b)
+	if err != nil {
+		return nil, err
+	}
+	return resA.Diff(resB), nil
+}
+
+// LookupHost resolves host and lookups each of its ipv4 addresses in database.
+// The context only applies to the resolution. IPv6 addresses and addresses not found in db are skipped.
+func (db *DB) LookupHost(ctx context.Context, host string) ([]*Result, error) {
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot resolve host")
+	}
+	results := make([]*Result, 0, len(addrs))
+	for _, addr := range addrs {
+		res, err := db.lookupNetIPV4(addr.IP)
+		if errors.Cause(err) == ErrInvalidIP {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if res != nil {
+			results = append(results, res)
+		}
+	}
+	return results, nil
+}
+
 // parses db file header
 func (db *DB) readHeader() error {
 	var err error
@@ -160,10 +455,9 @@ func (db *DB) readHeader() error {
 	if err != nil {
 		return err
 	}
-	switch t {
-	case uint8(PX1), uint8(PX2), uint8(PX3), uint8(PX4):
+	if t >= uint8(PX1) && t <= uint8(PX11) {
 		db.header.Type = DbType(t)
-	default:
+	} else {
 		db.header.Type = UnknownDbType
 	}
 	if db.header.Type == UnknownDbType {
@@ -175,9 +469,33 @@ func (db *DB) readHeader() error {
 	if err = db.readHeaderCounts(); err != nil {
 		return err
 	}
+	if err = db.readHeaderProduct(); err != nil {
+		return err
+	}
 	return db.readHeaderAddrs()
 }
 
+// logs the header anomalies not preventing lookups
+func (db *DB) warnHeader() {
+	if db.header.IndexBaseAddr == 0 {
+		db.warnf("%s has no ipv4 index, lookups search all the records", db.Version())
+	}
+	if db.header.IPv6Count == 0 && db.header.IPv6BaseAddr != 0 {
+		db.warnf("%s has an ipv6 section but no ipv6 record", db.Version())
+	}
+}
+
+// parses product and license codes in db file header, both 0 in files released before 2021
+func (db *DB) readHeaderProduct() error {
+	var err error
+	db.header.ProductCode, err = db.readUint8(29)
+	if err != nil {
+		return err
+	}
+	db.header.LicenseCode, err = db.readUint8(30)
+	return err
+}
+
 // parses date in db file header
 func (db *DB) readHeaderDate() error {
 	year, err := db.readUint8(2)
@@ -190,7 +508,15 @@ func (db *DB) readHeaderDate() error {
 		return err
 	}
 	db.header.Day, err = db.readUint8(4)
-	return err
+	if err != nil {
+		return err
+	}
+	// the date must be a valid calendar day, rather than being normalized as by time.Date
+	date := db.Date()
+	if date.Month() != time.Month(db.header.Month) || date.Day() != int(db.header.Day) {
+		return fmt.Errorf("invalid db date %d-%0.2d-%0.2d", db.header.Year, db.header.Month, db.header.Day)
+	}
+	return nil
 }
 
 // parses counts in db file header
@@ -222,11 +548,67 @@ func (db *DB) readHeaderAddrs() error {
 		return err
 	}
 	db.header.IndexBaseAddr, err = db.readUint32(21)
-	return err
+	if err != nil {
+		return err
+	}
+	if err = db.readHeaderIPv6(); err != nil {
+		return err
+	}
+	return db.checkRecordsBounds()
+}
+
+// parses ipv6 count and addrs in db file header
+func (db *DB) readHeaderIPv6() error {
+	var err error
+	db.header.IPv6Count, err = db.readUint32(13)
+	if err != nil {
+		return err
+	}
+	db.header.IPv6BaseAddr, err = db.readUint32(17)
+	if err != nil {
+		return err
+	}
+	db.header.IPv6IndexAddr, err = db.readUint32(25)
+	if err != nil {
+		return err
+	}
+	// ipv6 records start with a 16 bytes addr
+	db.header.IPv6ColumnSize = 16 + uint16(db.header.Cols-1)<<2
+	return nil
+}
+
+// checks that all the records declared in header fit in file, after the header
+func (db *DB) checkRecordsBounds() error {
+	err := db.checkSectionBounds("records", db.header.Count, uint16(db.header.IPv4ColumnSize), db.header.BaseAddr)
+	if err != nil || db.header.IPv6Count == 0 {
+		return err
+	}
+	return db.checkSectionBounds("ipv6 records", db.header.IPv6Count, db.header.IPv6ColumnSize, db.header.IPv6BaseAddr)
+}
+
+// checks that the count rows of size bytes at the file addr base fit in file, after the header, so that the rows
+// offsets computed in 32 bits can neither underflow nor overflow
+func (db *DB) checkSectionBounds(name string, count uint32, size uint16, base uint32) error {
+	if base <= headerSize {
+		return fmt.Errorf("invalid db format: %s base address %d within the %d bytes header", name, base, headerSize)
+	}
+	end := uint64(base) - 1 + uint64(count)*uint64(size)
+	if end > uint64(db.size()) {
+		return fmt.Errorf(
+			"invalid db format: %d %s of %d bytes at offset %d end at %d, beyond file size %d",
+			count,
+			name,
+			size,
+			base,
+			end,
+			db.size(),
+		)
+	}
+	return nil
 }
 
 // compute field positions according to type
-func (db *DB) computePositions() {
+func (db *DB) computePositions() error {
 	db.positions = &positions{}
 	if countryPos[db.header.Type] != 0 {
 		db.positions.Country = (countryPos[db.header.Type] - 1) << 2
@@ -243,64 +625,308 @@ func (db *DB) computePositions() {
 	if proxytypePos[db.header.Type] != 0 {
 		db.positions.Proxy = (proxytypePos[db.header.Type] - 1) << 2
 	}
+	db.positions.Domain = fieldPosition(domainPos[db.header.Type])
+	db.positions.UsageType = fieldPosition(usageTypePos[db.header.Type])
+	db.positions.ASN = fieldPosition(asnPos[db.header.Type])
+	db.positions.AS = fieldPosition(asPos[db.header.Type])
+	db.positions.LastSeen = fieldPosition(lastSeenPos[db.header.Type])
+	db.positions.Threat = fieldPosition(threatPos[db.header.Type])
+	db.positions.Provider = fieldPosition(providerPos[db.header.Type])
+	for f, col := range db.opts.columnLayout {
+		pos := db.positions.field(f)
+		if pos == nil {
+			return fmt.Errorf("unknown field %d", f)
+		}
+		if col < 2 || col > db.header.Cols {
+			return fmt.Errorf("%s column %d out of the %d columns", f, col, db.header.Cols)
+		}
+		*pos = fieldPosition(col)
+	}
+	return nil
 }
 
-// read and store all ipv4 indexes
+// gets the position of the field f, nil for unknown fields
+func (p *positions) field(f Field) *uint8 {
+	switch f {
+	case FieldCountry, FieldCountryCode, FieldCountryRaw:
+		return &p.Country
+	case FieldProxy, FieldRawProxy:
+		return &p.Proxy
+	case FieldRegion:
+		return &p.Region
+	case FieldCity:
+		return &p.City
+	case FieldISP, FieldISPCanonical:
+		return &p.ISP
+	case FieldDomain:
+		return &p.Domain
+	case FieldUsageType:
+		return &p.UsageType
+	case FieldASN:
+		return &p.ASN
+	case FieldAS:
+		return &p.AS
+	case FieldLastSeen:
+		return &p.LastSeen
+	case FieldThreat:
+		return &p.Threat
+	case FieldProvider:
+		return &p.Provider
+	}
+	return nil
+}
+
+// gets the byte position in a row of a field column index, 0 if the field is missing
+func fieldPosition(idx uint8) uint8 {
+	if idx == 0 {
+		return 0
+	}
+	return (idx - 1) << 2
+}
+
+// read and store all ipv4 indexes.
+// A db without index, its index base addr being 0, gets buckets spanning all its rows, lookups searching them all.
 func (db *DB) readIPv4Indexes() error {
-	pos := db.header.IndexBaseAddr
-	for i := 0; i < maxIndexes; i++ {
-		start, err := db.readUint32(pos - 1)
-		if err != nil {
-			return err
+	if db.header.IndexBaseAddr == 0 {
+		last := uint32(0)
+		if db.header.Count > 1 {
+			last = db.header.Count - 2
 		}
-		end, err := db.readUint32(pos + 3)
+		for i := range db.ipv4Indexes {
+			db.ipv4Indexes[i] = [2]uint32{0, last}
+		}
+		return nil
+	}
+	if db.opts.lazyIndex {
+		db.lazyIndexes = &lazyIndexes{}
+		return nil
+	}
+	for i := uint32(0); i < maxIndexes; i++ {
+		start, end, err := db.readIPv4Index(i)
 		if err != nil {
 			return err
 		}
 		db.ipv4Indexes[i][0] = start
 		db.ipv4Indexes[i][1] = end
-		pos += 8
 	}
 	return nil
 }
 
+// reads the ipv4 index bucket i in file, checking its rows range
+func (db *DB) readIPv4Index(i uint32) (uint32, uint32, error) {
+	return db.readIndexBucket("index", db.header.IndexBaseAddr, db.header.Count, i)
+}
+
+// read and store all ipv6 indexes, the ipv6 index being bucketed by the top 16 bits of addrs as the ipv4 one.
+// Files without ipv6 section are skipped, the ones without ipv6 index get buckets spanning all the ipv6 rows.
+func (db *DB) readIPv6Indexes() error {
+	if db.header.IPv6Count == 0 {
+		return nil
+	}
+	if db.header.IPv6IndexAddr == 0 {
+		last := uint32(0)
+		if db.header.IPv6Count > 1 {
+			last = db.header.IPv6Count - 2
+		}
+		for i := range db.ipv6Indexes {
+			db.ipv6Indexes[i] = [2]uint32{0, last}
+		}
+		return nil
+	}
+	if db.opts.lazyIndex {
+		db.lazyIPv6Indexes = &lazyIndexes{}
+		return nil
+	}
+	for i := uint32(0); i < maxIndexes; i++ {
+		start, end, err := db.readIPv6Index(i)
+		if err != nil {
+			return err
+		}
+		db.ipv6Indexes[i][0] = start
+		db.ipv6Indexes[i][1] = end
+	}
+	return nil
+}
+
+// reads the ipv6 index bucket i in file, checking its rows range
+func (db *DB) readIPv6Index(i uint32) (uint32, uint32, error) {
+	return db.readIndexBucket("ipv6 index", db.header.IPv6IndexAddr, db.header.IPv6Count, i)
+}
+
+// reads the bucket i of the index named name at the file addr base, checking its rows range against the count rows it
+// indexes
+func (db *DB) readIndexBucket(name string, base, count, i uint32) (uint32, uint32, error) {
+	off, ok := fileOffset(base, uint64(i)*8)
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid %s bucket %d: address %d out of the file", name, i, base)
+	}
+	start, err := db.readUint32(off)
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := db.readUint32(off + 4)
+	if err != nil {
+		return 0, 0, err
+	}
+	if start > end {
+		return 0, 0, fmt.Errorf("invalid %s bucket %d: start row %d above end row %d", name, i, start, end)
+	}
+	if end > count {
+		return 0, 0, fmt.Errorf("invalid %s bucket %d: end row %d beyond the %d records", name, i, end, count)
+	}
+	return start, end, nil
+}
+
+// gets the ipv4 index bucket i, reading it on first access when indexes are lazily loaded
+func (db *DB) ipv4Index(i uint32) (uint32, uint32, error) {
+	if db.lazyIndexes == nil {
+		return db.ipv4Indexes[i][0], db.ipv4Indexes[i][1], nil
+	}
+	db.lazyIndexes.once[i].Do(func() {
+		db.ipv4Indexes[i][0], db.ipv4Indexes[i][1], db.lazyIndexes.errs[i] = db.readIPv4Index(i)
+	})
+	if err := db.lazyIndexes.errs[i]; err != nil {
+		return 0, 0, errors.Annotate(err, "cannot read db index")
+	}
+	return db.ipv4Indexes[i][0], db.ipv4Indexes[i][1], nil
+}
+
+// gets the ipv6 index bucket i, reading it on first access when indexes are lazily loaded
+func (db *DB) ipv6Index(i uint32) (uint32, uint32, error) {
+	if db.lazyIPv6Indexes == nil {
+		return db.ipv6Indexes[i][0], db.ipv6Indexes[i][1], nil
+	}
+	db.lazyIPv6Indexes.once[i].Do(func() {
+		db.ipv6Indexes[i][0], db.ipv6Indexes[i][1], db.lazyIPv6Indexes.errs[i] = db.readIPv6Index(i)
+	})
+	if err := db.lazyIPv6Indexes.errs[i]; err != nil {
+		return 0, 0, errors.Annotate(err, "cannot read db ipv6 index")
+	}
+	return db.ipv6Indexes[i][0], db.ipv6Indexes[i][1], nil
+}
+
 // lookups a record in db for an ipv4 addr
 func (db *DB) lookupIPV4(ip uint32) (*Result, error) {
-	pos, err := db.findPosForIPV4(ip)
-	if err != nil {
+	res := &Result{}
+	found, err := db.lookupIPV4Into(ip, res)
+	if err != nil || !found {
 		return nil, err
 	}
+	return res, nil
+}
+
+// lookups a record in db for an ipv4 addr, decoding it into the empty result res
+func (db *DB) lookupIPV4Into(ip uint32, res *Result) (bool, error) {
+	if db.opts.tracer != nil {
+		return db.tracedLookupIPV4Into(ip, res)
+	}
+	_, found, err := db.lookupIPV4Row(ip, res)
+	return found, err
+}
+
+// lookups a record in db for an ipv4 addr as lookupIPV4Into does, also returning the byte offset of the row holding
+// it, 0 when the result is not read from a row
+func (db *DB) lookupIPV4Row(ip uint32, res *Result) (uint32, bool, error) {
+	if err := db.checkRecords(); err != nil {
+		return 0, false, err
+	}
+	reserved := (db.opts.reserved || db.opts.reservedFlag) && isReservedIPV4(ip)
+	if db.opts.reserved && reserved {
+		if db.opts.reservedResult == nil {
+			return 0, false, ErrReserved
+		}
+		res.copyFrom(db.opts.reservedResult)
+		res.IP = res.ipv4(ip)
+		res.Reserved = true
+		return 0, true, nil
+	}
+	// records are contiguous, the addrs not found being the ones out of their bounds
+	if ip < db.bounds[0] || ip > db.bounds[1] {
+		return 0, db.notFoundResult(ip, res), nil
+	}
+	pos := db.readCachedGap(ip, res)
 	if pos == 0 {
-		return nil, nil
+		var err error
+		if pos, err = db.findPosForIPV4(ip); err != nil {
+			return 0, false, err
+		}
+		if pos == 0 {
+			return 0, db.notFoundResult(ip, res), nil
+		}
+		if err := db.readCachedIPV4Record(pos, res); err != nil {
+			return 0, false, err
+		}
 	}
-	res, err := db.readIPV4Record(pos + 1)
-	if err != nil {
-		return nil, err
+	res.IP = res.ipv4(ip)
+	res.Reserved = reserved
+	if db.positions.Proxy != 0 && res.Proxy == ProxyNA {
+		if db.opts.strictProxy {
+			return 0, false, errors.Annotatef(ErrUnclassified, "%s proxy type %q", res.IP, strOrEmpty(res.RawProxy))
+		}
+		raw := strOrEmpty(res.RawProxy)
+		if _, warned := db.unknownProxies.LoadOrStore(raw, struct{}{}); !warned {
+			db.warnf("%s unknown proxy type %q", res.IP, raw)
+		}
 	}
-	res.IP = intToIPV4(ip)
-	return res, nil
+	return pos, true, nil
+}
+
+// copies the result configured for addrs not found into res, returning false if unset
+func (db *DB) notFoundResult(ip uint32, res *Result) bool {
+	if db.opts.notFoundResult == nil {
+		return false
+	}
+	res.copyFrom(db.opts.notFoundResult)
+	res.IP = res.ipv4(ip)
+	return true
 }
 
 // lookups a pos in db for an ipv4 addr
 func (db *DB) findPosForIPV4(ip uint32) (uint32, error) {
-	indexaddr := ip >> 16
-	low := db.ipv4Indexes[indexaddr][0]
-	high := db.ipv4Indexes[indexaddr][1]
+	return db.findPos(db.ipv4(), uint128{lo: uint64(ip)})
+}
+
+// lookups the byte offset of the row holding the addr ip among the rows of the family f, 0 when not found.
+// A record covers the addrs from its ipFrom up to the ipFrom of the next record (its ipTo) included, so an addr on
+// the boundary of two records is held by both of them and goes to the first one the search meets. A row starting
+// where the next one starts holds no addr. Rows out of order make ranges overlap beyond their boundary addr, the
+// record with the lowest ipFrom then wins among the ones next to the record the search meets.
+func (db *DB) findPos(f addrFamily, ip uint128) (uint32, error) {
+	if err := db.checkRecords(); err != nil {
+		return 0, err
+	}
+	low, high, err := f.bucket(ip)
+	if err != nil {
+		return 0, err
+	}
 	for low <= high {
 		mid := (low + high) / 2
-		rowOffset := db.header.BaseAddr + (mid * uint32(db.header.IPv4ColumnSize)) - 1
-		ipFrom, err := db.readUint32(rowOffset)
+		ipFrom, err := f.readAddr(f.rowOffset(mid))
 		if err != nil {
 			return 0, errors.Annotate(err, "cannot read db index")
 		}
-		ipTo, err := db.readUint32(rowOffset + uint32(db.header.IPv4ColumnSize))
-		if err != nil {
-			return 0, errors.Annotate(err, "cannot read db index")
+		// the last row holds no record but the upper bound of the previous one, and may end the file
+		ipTo := f.maxAddr()
+		if mid+1 < f.rows() {
+			if ipTo, err = f.readAddr(f.rowOffset(mid + 1)); err != nil {
+				return 0, errors.Annotate(err, "cannot read db index")
+			}
 		}
-		if ipFrom <= ip && ipTo >= ip {
-			return rowOffset, nil
+		if ipFrom.cmp(ip) <= 0 && ipTo.cmp(ip) >= 0 {
+			switch {
+			case mid+1 == f.rows() && ip == ipFrom && mid > 0:
+				return db.resolveOverlap(f, mid-1, ip)
+			case mid+1 == f.rows():
+				// beyond the records
+				return 0, nil
+			}
+			return db.resolveOverlap(f, mid, ip)
 		}
-		if ipFrom > ip {
+		if ipFrom.cmp(ip) > 0 {
+			if mid == 0 {
+				// below the first row, high can not go lower
+				break
+			}
 			high = mid - 1
 		} else {
 			low = mid + 1
@@ -309,112 +935,186 @@ func (db *DB) findPosForIPV4(ip uint32) (uint32, error) {
 	return 0, nil
 }
 
-// gets the byte offset for a field
-func (db *DB) getIPV4ByteOffset(field string, baseOffset uint32) uint32 {
-	var idx uint8
-
-	switch field {
-	case "proxy":
-		idx = (proxytypePos[db.header.Type] - 1) << 2
-	case "country":
-		idx = (countryPos[db.header.Type] - 1) << 2
-	case "region":
-		idx = (regionPos[db.header.Type] - 1) << 2
-	case "city":
-		idx = (cityPos[db.header.Type] - 1) << 2
-	case "isp":
-		idx = (ispPos[db.header.Type] - 1) << 2
-	default:
-		return 0
+// gets the byte offset of the row holding ip, the search having met the record of the row i of the family f holding
+// it. The rows starting where the next one starts are passed over. The rows around i out of order, starting above a
+// following row, are then checked for a record starting lower and holding ip beyond its ipTo boundary addr.
+func (db *DB) resolveOverlap(f addrFamily, i uint32, ip uint128) (uint32, error) {
+	readFrom := func(j uint32) (uint128, error) {
+		addr, err := f.readAddr(f.rowOffset(j))
+		return addr, errors.Annotate(err, "cannot read db index")
 	}
-	return baseOffset + uint32(idx)
+	from, err := readFrom(i)
+	if err != nil {
+		return 0, err
+	}
+	to, err := readFrom(i + 1)
+	if err != nil {
+		return 0, err
+	}
+	for from == to && i+2 < f.rows() {
+		i, from = i+1, to
+		if to, err = readFrom(i + 1); err != nil {
+			return 0, err
+		}
+	}
+	// the rows after i going down are out of order, the ones starting lower may overlap i
+	for j, last := i+2, to; j+1 < f.rows(); j++ {
+		next, err := readFrom(j)
+		if err != nil {
+			return 0, err
+		}
+		if next.cmp(last) >= 0 {
+			break
+		}
+		last = next
+		if next.cmp(from) >= 0 || next.cmp(ip) > 0 {
+			continue
+		}
+		end, err := readFrom(j + 1)
+		if err != nil {
+			return 0, err
+		}
+		if end.cmp(ip) > 0 {
+			i, from = j, next
+		}
+	}
+	// the rows before i starting above it are out of order, the one before them may start lower and overlap i
+	for j := i; j > 0; j-- {
+		prev, err := readFrom(j - 1)
+		if err != nil {
+			return 0, err
+		}
+		if prev.cmp(from) > 0 {
+			continue
+		}
+		end, err := readFrom(j)
+		if err != nil {
+			return 0, err
+		}
+		if prev.cmp(ip) > 0 || end.cmp(ip) <= 0 {
+			break
+		}
+		i, from = j-1, prev
+	}
+	return f.rowOffset(i), nil
+}
+
+// gets the byte offset of the row i, BaseAddr being checked to be beyond the header when opening the db
+func (db *DB) rowOffset(i uint32) uint32 {
+	return db.header.BaseAddr - 1 + i*uint32(db.header.IPv4ColumnSize)
 }
 
 // reads the Proxy field for record
 func (db *DB) readRecordProxy(res *Result, off uint32) error {
 	if db.positions.Proxy != 0 {
-		addr, err := db.readUint32(db.getIPV4ByteOffset("proxy", off) - 1)
+		addr, err := db.readUint32(off + uint32(db.positions.Proxy))
 		if err != nil {
 			return err
 		}
-		b, err := db.readStr(addr)
+		b, err := db.readResultStr(res, addr, "proxy type")
 		if err != nil {
 			return err
 		}
 		res.Proxy = proxyNameToProxyType(b)
-		return nil
+		res.RawProxy = res.str(b)
+		return db.expireProxy(res, off)
 	}
 	res.Proxy = ProxyNA
 	return nil
 }
 
+// reads the proxy of a record last seen beyond the maximum set by WithMaxLastSeen as ProxyNOT
+func (db *DB) expireProxy(res *Result, off uint32) error {
+	if db.opts.maxLastSeenDays <= 0 || db.positions.LastSeen == 0 || res.Proxy == ProxyNA || res.Proxy == ProxyNOT {
+		return nil
+	}
+	lastSeen, err := db.readRecordStr(res, off, db.positions.LastSeen, "last seen")
+	if err != nil {
+		return err
+	}
+	if days, ok := (&Result{LastSeen: lastSeen}).LastSeenDays(); ok && days > db.opts.maxLastSeenDays {
+		res.Proxy = ProxyNOT
+	}
+	return nil
+}
+
 // reads the Country field for record
 func (db *DB) readRecordCountry(res *Result, off uint32) error {
-	pos, err := db.readUint32(db.getIPV4ByteOffset("country", off) - 1)
+	pos, err := db.readUint32(off + uint32(db.positions.Country))
 	if err != nil {
 		return err
 	}
-	short, err := db.readStr(pos)
+	short, err := db.readResultStr(res, pos, "country short name")
 	if err != nil {
 		return err
 	}
-	long, err := db.readStr(pos + 3)
+	// the long name follows the short one, which takes at least 2 bytes: shorter ones, such as "-", are padded
+	long, err := db.readResultStr(res, pos+1+max32(uint32(len(short)), 2), "country long name")
 	if err != nil {
 		return err
 	}
 	if short != "" && short != "-" {
-		res.CountryCode = &short
+		res.CountryCode = res.str(short)
 	}
 	if long != "" && long != "-" {
-		res.Country = &long
+		res.Country = res.str(long)
+	}
+	if db.opts.isoCountryNames && res.CountryCode != nil {
+		if name, ok := isoCountryNames[*res.CountryCode]; ok {
+			res.CountryRaw = res.Country
+			res.Country = res.str(name)
+		}
 	}
 	return nil
 }
 
 // reads the Region field for record
 func (db *DB) readRecordRegion(res *Result, off uint32) error {
-	pos, err := db.readUint32(db.getIPV4ByteOffset("region", off) - 1)
+	pos, err := db.readUint32(off + uint32(db.positions.Region))
 	if err != nil {
 		return err
 	}
-	region, err := db.readStr(pos)
+	region, err := db.readResultStr(res, pos, "region")
 	if err != nil {
 		return err
 	}
 	if region != "" && region != "-" {
-		res.Region = &region
+		res.Region = res.str(region)
 	}
 	return nil
 }
 
 // reads the City field for record
 func (db *DB) readRecordCity(res *Result, off uint32) error {
-	pos, err := db.readUint32(db.getIPV4ByteOffset("city", off) - 1)
+	pos, err := db.readUint32(off + uint32(db.positions.City))
 	if err != nil {
 		return err
 	}
-	city, err := db.readStr(pos)
+	city, err := db.readResultStr(res, pos, "city")
 	if err != nil {
 		return err
 	}
 	if city != "" && city != "-" {
-		res.City = &city
+		res.City = res.str(city)
 	}
 	return nil
 }
 
 // reads the ISP field for record
 func (db *DB) readRecordISP(res *Result, off uint32) error {
-	pos, err := db.readUint32(db.getIPV4ByteOffset("isp", off) - 1)
+	pos, err := db.readUint32(off + uint32(db.positions.ISP))
 	if err != nil {
 		return err
 	}
-	isp, err := db.readStr(pos)
+	isp, err := db.readResultStr(res, pos, "isp")
 	if err != nil {
 		return err
 	}
 	if isp != "" && isp != "-" {
-		res.ISP = &isp
+		res.ISP = res.str(isp)
+		if db.opts.canonicalISP {
+			res.ISPCanonical = res.str(CanonicalISP(isp))
+		}
 	}
 	return nil
 }
@@ -422,33 +1122,95 @@ func (db *DB) readRecordISP(res *Result, off uint32) error {
 // reads a record
 func (db *DB) readIPV4Record(off uint32) (*Result, error) {
 	r := &Result{}
-	if err := db.readRecordCountry(r, off); err != nil {
+	if err := db.readIPV4RecordInto(off, r); err != nil {
 		return nil, err
 	}
+	return r, nil
+}
+
+// reads a record into the empty result r
+func (db *DB) readIPV4RecordInto(off uint32, r *Result) error {
+	r.Source = r.source(db.source)
+	if err := db.readRecordCountry(r, off); err != nil {
+		return err
+	}
 	if db.Type() >= PX2 {
 		if err := db.readRecordProxy(r, off); err != nil {
-			return nil, err
+			return err
 		}
 	}
 	if db.Type() >= PX3 {
 		if err := db.readRecordRegion(r, off); err != nil {
-			return nil, err
+			return err
 		}
 	}
-	if db.Type() == PX4 {
+	if db.Type() >= PX4 {
 		if err := db.readRecordCity(r, off); err != nil {
-			return nil, err
+			return err
 		}
 		if err := db.readRecordISP(r, off); err != nil {
-			return nil, err
+			return err
 		}
 	}
-	return r, nil
+	if db.Type() >= PX5 {
+		if err := db.readRecordExtraFields(r, off); err != nil {
+			return err
+		}
+	}
+	if db.opts.emptyInsteadOfNil {
+		for f := FieldCountry; f <= lastField; f++ {
+			if p := f.ptr(r); p != nil && *p == nil {
+				*p = r.str("")
+			}
+		}
+	}
+	return nil
+}
+
+// reads the fields of types PX5 and above for record
+func (db *DB) readRecordExtraFields(res *Result, off uint32) error {
+	fields := []struct {
+		pos   uint8
+		name  string
+		value **string
+	}{
+		{db.positions.Domain, "domain", &res.Domain},
+		{db.positions.UsageType, "usage type", &res.UsageType},
+		{db.positions.ASN, "asn", &res.ASN},
+		{db.positions.AS, "as", &res.AS},
+		{db.positions.LastSeen, "last seen", &res.LastSeen},
+		{db.positions.Threat, "threat", &res.Threat},
+		{db.positions.Provider, "provider", &res.Provider},
+	}
+	for _, f := range fields {
+		if f.pos == 0 {
+			continue
+		}
+		v, err := db.readRecordStr(res, off, f.pos, f.name)
+		if err != nil {
+			return err
+		}
+		*f.value = v
+	}
+	return nil
+}
+
+// reads the string field at position pos for record, nil when empty or a placeholder
+func (db *DB) readRecordStr(res *Result, off uint32, pos uint8, name string) (*string, error) {
+	strPos, err := db.readUint32(off + uint32(pos))
+	if err != nil {
+		return nil, err
+	}
+	v, err := db.readResultStr(res, strPos, name)
+	if err != nil || v == "" || v == "-" {
+		return nil, err
+	}
+	return res.str(v), nil
 }
 
 // reads a uint8 value at position in file
 func (db *DB) readUint8(pos uint32) (uint8, error) {
-	if pos > db.dataSize-1 {
+	if pos >= db.dataSize {
 		return 0, io.EOF
 	}
 	return db.data[pos], nil
@@ -467,7 +1229,7 @@ func (db *DB) readUint16(pos uint32) (uint16, error) {
 
 // reads a uint32 value at position in file
 func (db *DB) readUint32(pos uint32) (uint32, error) {
-	if pos > db.dataSize-4 {
+	if uint64(pos)+4 > uint64(db.dataSize) {
 		return 0, io.EOF
 	}
 	bin := db.data[pos : pos+4]
@@ -476,9 +1238,15 @@ func (db *DB) readUint32(pos uint32) (uint32, error) {
 
 // reads a byte slice at position in file
 func (db *DB) readByteSlice(pos uint32) ([]byte, error) {
-	if pos > db.dataSize-1 {
-		return nil, io.EOF
+	b, err := db.strBytes(pos)
+	if err != nil || b == nil {
+		return nil, err
 	}
+	return append([]byte(nil), b...), nil
+}
+
+// gets the bytes of the string at position in file, sharing the file memory
+func (db *DB) strBytes(pos uint32) ([]byte, error) {
 	size, err := db.readUint8(pos)
 	if err != nil {
 		return nil, err
@@ -486,29 +1254,77 @@ func (db *DB) readByteSlice(pos uint32) ([]byte, error) {
 	if size == 0 {
 		return nil, nil
 	}
-	if pos+uint32(size) > db.dataSize {
+	if uint64(pos)+uint64(size) >= uint64(db.dataSize) {
 		return nil, io.EOF
 	}
-	b := make([]byte, size)
-	for i := uint8(0); i < size; i++ {
-		b[i] = db.data[pos+uint32(1+i)]
+	return db.data[pos+1 : pos+1+uint32(size)], nil
+}
+
+// reads the string of a field at position in file
+func (db *DB) readFieldStr(pos uint32, field string) (string, error) {
+	s, err := db.readStr(pos)
+	if err != nil {
+		return "", errors.Annotatef(err, "%s offset out of range", field)
+	}
+	return s, nil
+}
+
+// reads the string of a field of res at position in file, decoded into the storage of res when pooled
+func (db *DB) readResultStr(res *Result, pos uint32, field string) (string, error) {
+	if res.arena == nil || db.strings != nil || db.opts.interner != nil {
+		return db.readFieldStr(pos, field)
 	}
-	return b, nil
+	b, err := db.strBytes(pos)
+	if err != nil {
+		return "", errors.Annotatef(err, "%s offset out of range", field)
+	}
+	return res.bytesStr(b), nil
 }
 
-// reads a string at position in file
+// reads a string at position in file, from the string cache when enabled
 func (db *DB) readStr(pos uint32) (string, error) {
+	if db.strings != nil {
+		return db.strings.getOrDecode(pos, func() (string, error) {
+			return db.decodeStr(pos)
+		})
+	}
+	return db.decodeStr(pos)
+}
+
+// decodes the string at pos, interning it when an interner is set
+func (db *DB) decodeStr(pos uint32) (string, error) {
 	b, err := db.readByteSlice(pos)
 	if err != nil {
 		return "", err
 	}
+	if db.opts.interner != nil {
+		return db.opts.interner.intern(b), nil
+	}
 	return string(b), nil
 }
 
+// ParseIPv4 parses a dot notation (1.2.3.4) ipv4 address to its numeric value, as used by LookupIPV4Num.
+// It returns ErrInvalidIP if s is not an ipv4 address.
+func ParseIPv4(s string) (uint32, error) {
+	return netIPV4ToInt(net.ParseIP(s))
+}
+
+// ParseIPv6 parses an ipv6 address to its 16 bytes form, ipv4 addresses being parsed as ipv4-mapped ipv6
+// addresses. It returns ErrInvalidIP if s is not an ip address.
+func ParseIPv6(s string) ([16]byte, error) {
+	var b [16]byte
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return b, ErrInvalidIP
+	}
+	copy(b[:], ip.To16())
+	return b, nil
+}
+
 // string ip to unsigned 32 bit number
 func ipV4ToInt(ip net.IP) (uint32, error) {
 	if ip == nil {
-		return 0, fmt.Errorf("invalid IP")
+		return 0, ErrInvalidIP
 	}
 	if len(ip) == 16 {
 		return binary.BigEndian.Uint32(ip[12:16]), nil
@@ -516,6 +1332,15 @@ func ipV4ToInt(ip net.IP) (uint32, error) {
 	return binary.BigEndian.Uint32(ip), nil
 }
 
+// net.IP ipv4 to unsigned 32 bit number, ErrInvalidIP for an ipv6 addr, which low 32 bits ipV4ToInt reads
+func netIPV4ToInt(ip net.IP) (uint32, error) {
+	ip4 := ip.To4()
+	if ip4 == nil {
+		return 0, ErrInvalidIP
+	}
+	return binary.BigEndian.Uint32(ip4), nil
+}
+
 // string ip to unsigned 32 bit number
 func ipV4Dot2int(ipStr string) (uint32, error) {
 	return ipV4ToInt(net.ParseIP(ipStr))
@@ -523,7 +1348,58 @@ func ipV4Dot2int(ipStr string) (uint32, error) {
 
 // unsigned 32 bit number to ipv4 string
 func intToIPV4(num uint32) string {
+	return intToNetIPV4(num).String()
+}
+
+// unsigned 32 bit number to net.IP
+func intToNetIPV4(num uint32) net.IP {
 	ip := make(net.IP, 4)
 	binary.BigEndian.PutUint32(ip, num)
-	return ip.String()
+	return ip
+}
+
+// IsReserved reports whether ip is a private, shared (100.64.0.0/10), loopback, link-local, documentation
+// (192.0.2.0/24, 198.51.100.0/24 and 203.0.113.0/24), multicast, unspecified or future use (240.0.0.0/4, broadcast
+// included) ipv4 address, or a private, loopback, link-local, multicast or unspecified ipv6 address. Those addresses
+// are not routable on the public internet.
+func IsReserved(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		return isReservedIPV4(binary.BigEndian.Uint32(ip4))
+	}
+	return ip.IsPrivate() ||
+		ip.IsLoopback() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() ||
+		ip.IsUnspecified()
+}
+
+// reserved ipv4 addrs blocks, by their first addr and prefix length
+var reservedIPV4Blocks = [...]struct {
+	from uint32
+	ones uint
+}{
+	{0x00000000, 32}, // 0.0.0.0, unspecified
+	{0x0A000000, 8},  // 10.0.0.0/8, private
+	{0x64400000, 10}, // 100.64.0.0/10, shared address space
+	{0x7F000000, 8},  // 127.0.0.0/8, loopback
+	{0xA9FE0000, 16}, // 169.254.0.0/16, link-local
+	{0xAC100000, 12}, // 172.16.0.0/12, private
+	{0xC0000200, 24}, // 192.0.2.0/24, documentation
+	{0xC0A80000, 16}, // 192.168.0.0/16, private
+	{0xC6336400, 24}, // 198.51.100.0/24, documentation
+	{0xCB007100, 24}, // 203.0.113.0/24, documentation
+	{0xE0000000, 4},  // 224.0.0.0/4, multicast
+	{0xF0000000, 4},  // 240.0.0.0/4, future use, 255.255.255.255 broadcast included
+}
+
+// checks if the ipv4 addr ip is a reserved one, see IsReserved, without allocating a net.IP
+func isReservedIPV4(ip uint32) bool {
+	for _, b := range reservedIPV4Blocks {
+		if ip>>(32-b.ones) == b.from>>(32-b.ones) {
+			return true
+		}
+	}
+	return false
 }
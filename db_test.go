@@ -1,10 +1,21 @@
 package ip2proxy_test
 
 import (
+	"bytes"
+	"context"
 	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"math"
+	"math/big"
+	"net"
+	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/juju/errors"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
@@ -67,6 +78,8 @@ var _ = Describe("Db", func() {
 			if err != nil {
 				Fail("could not generate 4096 random bytes")
 			}
+			// a random type byte may be a valid type
+			b[0] = 0
 			db, err := FromBytes(b)
 			Expect(db).Should(BeNil())
 			Expect(err).To(HaveOccurred())
@@ -81,6 +94,16 @@ var _ = Describe("Db", func() {
 			Expect(err).To(BeNil())
 			Expect(db).ToNot(BeNil())
 		})
+		It("should returns an error on a truncated byte slice", func() {
+			b, err := ioutil.ReadFile(filepath.Join("testdata", "IP2PROXY-LITE-PX4.BIN"))
+			if err != nil {
+				Fail("could read db file")
+			}
+			db, err := FromBytes(b[:50000000])
+			Expect(db).Should(BeNil())
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("cannot read db header: invalid db format: 3445221 records of 24 bytes at offset 1048641 end at 83733944, beyond file size 50000000"))
+		})
 	})
 
 	Context("when correctly initialized", func() {
@@ -95,6 +118,18 @@ var _ = Describe("Db", func() {
 		It("should return the valid record count", func() {
 			Expect(db.Count()).To(Equal(uint32(3445221)))
 		})
+		It("should return the valid columns count and row size", func() {
+			Expect(db.Columns()).To(Equal(6))
+			Expect(db.RowSize()).To(Equal(24))
+		})
+		It("should hold ipv6 records", func() {
+			Expect(db.HasIPv6()).To(BeTrue())
+			Expect(db.IPv6Count()).To(Equal(uint32(2)))
+		})
+		It("should return no product and license codes", func() {
+			Expect(db.ProductCode()).To(Equal(uint8(0)))
+			Expect(db.LicenseCode()).To(Equal(uint8(0)))
+		})
 		It("should return the valid version string", func() {
 			Expect(db.Version()).To(Equal("PX4-2018-02-01"))
 		})
@@ -116,6 +151,89 @@ var _ = Describe("Db", func() {
 			Expect(res).To(BeNil())
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(Equal("invalid IP"))
+			Expect(err).To(Equal(ErrInvalidIP))
+		})
+		It("should parse numeric ips", func() {
+			ip, err := ParseIPv4("2.6.120.66")
+			Expect(err).To(BeNil())
+			Expect(ip).To(Equal(uint32(33978434)))
+			res, err := db.LookupIPV4Num(ip)
+			Expect(err).To(BeNil())
+			Expect(res.Proxy).To(Equal(ProxyPUB))
+			for _, invalid := range []string{"289.1.2.3", "::1", ""} {
+				_, err = ParseIPv4(invalid)
+				Expect(err).To(Equal(ErrInvalidIP), invalid)
+			}
+			ip6, err := ParseIPv6("2001:db8::1")
+			Expect(err).To(BeNil())
+			Expect(ip6).To(Equal([16]byte{0x20, 0x01, 0x0d, 0xb8, 15: 1}))
+			ip6, err = ParseIPv6("1.2.3.4")
+			Expect(err).To(BeNil())
+			Expect(ip6).To(Equal([16]byte{10: 0xff, 11: 0xff, 12: 1, 13: 2, 14: 3, 15: 4}))
+			_, err = ParseIPv6("lol")
+			Expect(err).To(Equal(ErrInvalidIP))
+		})
+		It("should lookup big ints", func() {
+			res, err := db.Lookup(big.NewInt(33978434))
+			Expect(err).To(BeNil())
+			Expect(res.IP).To(Equal("2.6.120.66"))
+			Expect(res.Proxy).To(Equal(ProxyPUB))
+			res, err = db.Lookup(big.NewInt(math.MaxUint32))
+			Expect(err).To(BeNil())
+			Expect(res.IP).To(Equal("255.255.255.255"))
+			ipv6 := new(big.Int).Lsh(big.NewInt(1), 32)
+			tooLarge := new(big.Int).Lsh(big.NewInt(1), 128)
+			for _, invalid := range []*big.Int{big.NewInt(-1), ipv6, tooLarge, nil} {
+				res, err = db.Lookup(invalid)
+				Expect(res).To(BeNil())
+				Expect(errors.Cause(err)).To(Equal(ErrInvalidIP), invalid.String())
+			}
+			Expect(err).To(MatchError("<nil> is out of the addrs range: invalid IP"))
+		})
+		It("should return an error for ipv6 addrs", func() {
+			// its low 32 bits being 2.6.120.66, a PUB addr
+			ip := net.ParseIP("2001:db8::206:7842")
+			_, _, err := db.LookupTimed(ip)
+			Expect(err).To(Equal(ErrInvalidIP))
+			_, _, err = db.LookupFlat(ip)
+			Expect(err).To(Equal(ErrInvalidIP))
+			_, err = db.IsSearchEngine(ip)
+			Expect(err).To(Equal(ErrInvalidIP))
+			_, _, err = db.Datacenter(ip)
+			Expect(err).To(Equal(ErrInvalidIP))
+			_, err = db.InCountry(ip, "FR")
+			Expect(err).To(Equal(ErrInvalidIP))
+			_, err = db.CompareIPs(net.ParseIP("2.6.120.66"), ip)
+			Expect(err).To(Equal(ErrInvalidIP))
+			_, err = db.LookupFloor(ip)
+			Expect(err).To(Equal(ErrInvalidIP))
+			res, err := db.LookupIPV4(net.ParseIP("::ffff:2.6.120.66"))
+			Expect(err).To(BeNil())
+			Expect(res.Proxy).To(Equal(ProxyPUB))
+		})
+		It("should time the lookups", func() {
+			res, elapsed, err := db.LookupTimed(net.ParseIP("2.6.120.66"))
+			Expect(err).To(BeNil())
+			Expect(res.Proxy).To(Equal(ProxyPUB))
+			Expect(elapsed).To(BeNumerically(">", 0))
+			res, _, err = db.LookupTimed(nil)
+			Expect(res).To(BeNil())
+			Expect(err).To(Equal(ErrInvalidIP))
+		})
+		It("should check the country of ips", func() {
+			for ip, expected := range map[string]bool{"31.31.77.107": true, "2.6.120.66": false} {
+				ok, err := db.InCountry(net.ParseIP(ip), "cz")
+				Expect(err).To(BeNil())
+				Expect(ok).To(Equal(expected), ip)
+			}
+			ok, err := db.InCountry(net.ParseIP("2.6.120.66"), "FR")
+			Expect(err).To(BeNil())
+			Expect(ok).To(BeTrue())
+			ok, err = db.InCountry(net.ParseIP("2.6.120.66"), "")
+			Expect(err).To(BeNil())
+			Expect(ok).To(BeFalse())
+			_, err = db.InCountry(nil, "FR")
+			Expect(err).To(Equal(ErrInvalidIP))
 		})
 		It("should return a valid info for proxy hosts", func() {
 			list := map[string]ProxyType{
@@ -191,5 +309,419 @@ var _ = Describe("Db", func() {
 				Expect(res.Region).To(Equal(expected))
 			}
 		})
+		It("should resolve the records sharing a boundary address", func() {
+			// a PUB record from 2.6.120.65 to 2.6.120.66, where a non proxy record starts,
+			// and a DCH record from 206.190.130.174 to 206.190.140.157, where a PUB record starts,
+			// the boundary addrs going to the first record the search meets
+			list := map[string]ProxyType{
+				"2.6.120.64":      ProxyNOT,
+				"2.6.120.65":      ProxyPUB,
+				"2.6.120.66":      ProxyPUB,
+				"2.6.120.67":      ProxyNOT,
+				"206.190.140.156": ProxyDCH,
+				"206.190.140.157": ProxyPUB,
+			}
+			for ip, expected := range list {
+				res, err := db.LookupIPV4Dot(ip)
+				Expect(err).To(BeNil())
+				Expect(res.Proxy).To(Equal(expected), ip)
+			}
+		})
+		It("should return a record for the first and last addresses", func() {
+			for _, ip := range []string{"0.0.0.0", "255.255.255.255"} {
+				res, err := db.LookupIPV4Dot(ip)
+				Expect(err).To(BeNil())
+				Expect(res).ToNot(BeNil())
+				Expect(res.IP).To(Equal(ip))
+			}
+		})
+		It("should lookup the addresses of a host", func() {
+			res, err := db.LookupHost(context.Background(), "localhost")
+			Expect(err).To(BeNil())
+			Expect(res).To(HaveLen(1))
+			Expect(res[0].IP).To(Equal("127.0.0.1"))
+		})
+		It("should return an error for an unresolvable host", func() {
+			res, err := db.LookupHost(context.Background(), "idonttexists.invalid")
+			Expect(res).To(BeNil())
+			Expect(err).To(HaveOccurred())
+		})
+	})
+	Context("when looking up records out of order", func() {
+		var b []byte
+		BeforeEach(func() {
+			var err error
+			b, err = ioutil.ReadFile(filepath.Join("testdata", "IP2PROXY-LITE-PX4.BIN"))
+			Expect(err).To(BeNil())
+		})
+		lookup := func(ip string) ProxyType {
+			db, err := FromBytes(b)
+			Expect(err).To(BeNil())
+			res, err := db.LookupIPV4Dot(ip)
+			Expect(err).To(BeNil())
+			Expect(res).ToNot(BeNil(), ip)
+			return res.Proxy
+		}
+		It("should return the record with the lowest ipFrom of the records before", func() {
+			builder, err := NewBuilder(PX4, time.Now())
+			Expect(err).To(BeNil())
+			Expect(builder.Add(dotToInt("10.0.0.0"), dotToInt("10.0.0.255"), &Result{Proxy: ProxyVPN})).To(Succeed())
+			Expect(builder.Add(dotToInt("10.0.1.0"), dotToInt("10.0.1.255"), &Result{Proxy: ProxyDCH})).To(Succeed())
+			Expect(builder.Add(dotToInt("10.0.2.0"), dotToInt("10.0.2.255"), &Result{Proxy: ProxyPUB})).To(Succeed())
+			b, err = builder.Bytes()
+			Expect(err).To(BeNil())
+			// moves the DCH record, following the leading non proxy row and the VPN one, to 10.0.2.128, the VPN record
+			// then ending there and overlapping the PUB record
+			binary.LittleEndian.PutUint32(b[binary.LittleEndian.Uint32(b[9:])-1+2*24:], dotToInt("10.0.2.128"))
+			Expect(lookup("10.0.1.50")).To(Equal(ProxyVPN))
+			Expect(lookup("10.0.2.50")).To(Equal(ProxyVPN))
+			Expect(lookup("10.0.2.200")).To(Equal(ProxyPUB))
+		})
+		It("should return the record with the lowest ipFrom of the records after", func() {
+			// moves the PUB record of 1.0.80.130 to 1.0.10.0, then ending at 1.0.80.131 and overlapping the non proxy
+			// record of 1.0.1.0 and the DCH record of 1.0.16.0
+			binary.LittleEndian.PutUint32(b[1048640+5*24:], 16779776)
+			Expect(lookup("1.0.5.0")).To(Equal(ProxyNOT))
+			Expect(lookup("1.0.12.0")).To(Equal(ProxyNOT))
+			Expect(lookup("1.0.20.0")).To(Equal(ProxyPUB))
+			Expect(lookup("1.0.50.0")).To(Equal(ProxyPUB))
+		})
+		It("should skip a record starting at the same addr as the next one", func() {
+			// moves the non proxy record of 1.0.1.0 to 1.0.16.0, where the DCH record starts
+			binary.LittleEndian.PutUint32(b[1048640+2*24:], 16781312)
+			db, err := FromBytes(b)
+			Expect(err).To(BeNil())
+			Expect(db.Verify()).To(Succeed())
+			Expect(lookup("1.0.1.0")).To(Equal(ProxyDCH))
+			Expect(lookup("1.0.16.0")).To(Equal(ProxyDCH))
+		})
+	})
+	Context("when reading corrupt string offsets", func() {
+		build := func() []byte {
+			b, err := NewBuilder(PX4, time.Now())
+			Expect(err).To(BeNil())
+			data, err := b.Bytes()
+			Expect(err).To(BeNil())
+			return data
+		}
+		// country column of the first record
+		countryOffset := 64 + 65536*8 + 8
+		It("should return an error naming the country short name", func() {
+			data := build()
+			binary.LittleEndian.PutUint32(data[countryOffset:], uint32(len(data)+10))
+			db, err := FromBytes(data)
+			Expect(err).To(BeNil())
+			res, err := db.LookupIPV4Dot("1.2.3.4")
+			Expect(res).To(BeNil())
+			Expect(err).To(MatchError("country short name offset out of range: EOF"))
+		})
+		It("should return an error naming the country long name", func() {
+			data := build()
+			// an empty short name in the last byte, the long name being beyond
+			data[len(data)-1] = 0
+			binary.LittleEndian.PutUint32(data[countryOffset:], uint32(len(data)-1))
+			db, err := FromBytes(data)
+			Expect(err).To(BeNil())
+			res, err := db.LookupIPV4Dot("1.2.3.4")
+			Expect(res).To(BeNil())
+			Expect(err).To(MatchError("country long name offset out of range: EOF"))
+		})
+	})
+	Context("when reading a corrupt header date", func() {
+		It("should return an error for an invalid date", func() {
+			for _, date := range [][2]byte{{0, 15}, {13, 1}, {2, 30}, {6, 0}} {
+				data, err := ioutil.ReadFile(filepath.Join("testdata", "PX11-SAMPLE.BIN"))
+				Expect(err).To(BeNil())
+				data[3], data[4] = date[0], date[1]
+				db, err := FromBytes(data)
+				Expect(db).Should(BeNil())
+				Expect(err).To(MatchError(fmt.Sprintf("cannot read db header: invalid db date 2021-%0.2d-%0.2d", date[0], date[1])))
+			}
+		})
+	})
+	Context("when reading corrupt header addrs", func() {
+		It("should return an error for records base addrs within the header", func() {
+			for _, base := range []uint32{0, 1, 64} {
+				data, err := ioutil.ReadFile(filepath.Join("testdata", "PX11-SAMPLE.BIN"))
+				Expect(err).To(BeNil())
+				binary.LittleEndian.PutUint32(data[9:], base)
+				db, err := FromBytes(data)
+				Expect(db).Should(BeNil())
+				Expect(err).To(MatchError(fmt.Sprintf("cannot read db header: invalid db format: records base address %d within the 64 bytes header", base)))
+			}
+		})
+		It("should return an error for an index addr at the end of the addressable", func() {
+			data, err := ioutil.ReadFile(filepath.Join("testdata", "PX11-SAMPLE.BIN"))
+			Expect(err).To(BeNil())
+			binary.LittleEndian.PutUint32(data[21:], math.MaxUint32)
+			for _, opts := range [][]Option{nil, {WithLazyIndex()}} {
+				db, err := FromBytes(data, opts...)
+				if err == nil {
+					_, err = db.LookupIPV4Dot("255.255.255.255")
+				}
+				Expect(err).To(HaveOccurred())
+			}
+		})
+	})
+	Context("when reading corrupt index buckets", func() {
+		read := func() []byte {
+			data, err := ioutil.ReadFile(filepath.Join("testdata", "PX11-SAMPLE.BIN"))
+			Expect(err).To(BeNil())
+			return data
+		}
+		It("should return an error for a bucket starting after its end", func() {
+			data := read()
+			binary.LittleEndian.PutUint32(data[64+5*8:], 9)
+			db, err := FromBytes(data)
+			Expect(db).Should(BeNil())
+			Expect(err).To(MatchError("cannot read db index: invalid index bucket 5: start row 9 above end row 0"))
+		})
+		It("should return an error for a bucket ending beyond the records", func() {
+			data := read()
+			binary.LittleEndian.PutUint32(data[64+7*8+4:], 13)
+			db, err := FromBytes(data, WithLazyIndex())
+			Expect(err).To(BeNil())
+			_, err = db.LookupIPV4Dot("0.7.0.0")
+			Expect(err).To(MatchError("cannot read db index: invalid index bucket 7: end row 13 beyond the 12 records"))
+		})
+		It("should read the ipv6 index buckets", func() {
+			data := read()
+			// an ipv6 section which index and rows are the ipv4 ones
+			binary.LittleEndian.PutUint32(data[13:], 12)
+			copy(data[17:21], data[9:13])
+			copy(data[25:29], data[21:25])
+			_, err := FromBytes(data)
+			Expect(err).To(BeNil())
+			binary.LittleEndian.PutUint32(data[13:], 5)
+			_, err = FromBytes(data)
+			Expect(err).To(MatchError("cannot read db ipv6 index: invalid ipv6 index bucket 768: end row 7 beyond the 5 records"))
+		})
+		It("should return an error for ipv6 records beyond the file", func() {
+			data := read()
+			binary.LittleEndian.PutUint32(data[13:], 1<<26)
+			copy(data[17:21], data[9:13])
+			_, err := FromBytes(data)
+			Expect(err).To(MatchError("cannot read db header: invalid db format: 67108864 ipv6 records of 64 bytes at offset 524353 end at 4295491648, beyond file size 525562"))
+		})
+	})
+	Context("when looking up addrs out of the records bounds", func() {
+		It("should not search the index", func() {
+			data, err := ioutil.ReadFile(filepath.Join("testdata", "PX11-SAMPLE.BIN"))
+			Expect(err).To(BeNil())
+			// the first record starting at 0.1.0.0, and an invalid first index bucket
+			binary.LittleEndian.PutUint32(data[64+65536*8:], 65536)
+			binary.LittleEndian.PutUint32(data[64:], 9)
+			db, err := FromBytes(data, WithLazyIndex())
+			Expect(err).To(BeNil())
+			from, _, err := db.IPv4Bounds()
+			Expect(err).To(BeNil())
+			Expect(from).To(Equal(uint32(65536)))
+			gaps, err := db.CoverageGaps()
+			Expect(err).To(BeNil())
+			Expect(gaps).To(Equal([]Range{{From: 0, To: 65535}}))
+			res, err := db.LookupIPV4Dot("0.0.255.255")
+			Expect(err).To(BeNil())
+			Expect(res).To(BeNil())
+			res, err = db.LookupIPV4Dot("0.1.0.0")
+			Expect(err).To(BeNil())
+			Expect(res.Proxy).To(Equal(ProxyNOT))
+		})
+		It("should search the ranges starting below the records", func() {
+			data, err := ioutil.ReadFile(filepath.Join("testdata", "PX11-SAMPLE.BIN"))
+			Expect(err).To(BeNil())
+			binary.LittleEndian.PutUint32(data[64+65536*8:], 65536)
+			db, err := FromBytes(data)
+			Expect(err).To(BeNil())
+			results, err := db.LookupAll(0, 65536)
+			Expect(err).To(BeNil())
+			Expect(results).To(HaveLen(1))
+			Expect(results[0].RangeFrom).To(Equal(uint32(65536)))
+			results, err = db.LookupAll(0, 65535)
+			Expect(err).To(BeNil())
+			Expect(results).To(BeEmpty())
+		})
+		It("should return a copy of the default result", func() {
+			data, err := ioutil.ReadFile(filepath.Join("testdata", "PX11-SAMPLE.BIN"))
+			Expect(err).To(BeNil())
+			binary.LittleEndian.PutUint32(data[64+65536*8:], 65536)
+			country := "unknown"
+			db, err := FromBytes(data, WithNotFoundDefault(&Result{Proxy: ProxyNOT, Country: &country}))
+			Expect(err).To(BeNil())
+			res, err := db.LookupIPV4Dot("0.0.1.2")
+			Expect(err).To(BeNil())
+			Expect(res.IP).To(Equal("0.0.1.2"))
+			Expect(res.Proxy).To(Equal(ProxyNOT))
+			*res.Country = "changed"
+			res, err = db.LookupIPV4Dot("0.0.1.3")
+			Expect(err).To(BeNil())
+			Expect(*res.Country).To(Equal("unknown"))
+			Expect(country).To(Equal("unknown"))
+			res, err = db.LookupIPV4Dot("1.0.0.1")
+			Expect(err).To(BeNil())
+			Expect(res.Proxy).To(Equal(ProxyVPN))
+		})
+	})
+	Context("when logging warnings", func() {
+		It("should log the recoverable anomalies", func() {
+			var buf bytes.Buffer
+			logger := log.New(&buf, "", 0)
+			data, err := ioutil.ReadFile(filepath.Join("testdata", "PX11-SAMPLE.BIN"))
+			Expect(err).To(BeNil())
+			binary.LittleEndian.PutUint32(data[21:], 0)
+			_, err = FromBytes(data, WithLogger(logger))
+			Expect(err).To(BeNil())
+			Expect(buf.String()).To(Equal("ip2proxy: PX11-2021-06-01 has no ipv4 index, lookups search all the records\n"))
+
+			buf.Reset()
+			var built bytes.Buffer
+			csv := `"16777216","16777471","XYZ","AU","Australia"`
+			Expect(BuildFromCSV(strings.NewReader(csv), &built, PX2, time.Date(2021, time.Month(6), 1, 0, 0, 0, 0, time.UTC))).To(Succeed())
+			db, err := FromBytes(built.Bytes(), WithLogger(logger))
+			Expect(err).To(BeNil())
+			_, err = db.LookupIPV4Dot("1.0.1.1")
+			Expect(err).To(BeNil())
+			Expect(buf.String()).To(BeEmpty())
+			res, err := db.LookupIPV4Dot("1.0.0.1")
+			Expect(err).To(BeNil())
+			Expect(res.Proxy).To(Equal(ProxyNA))
+			// each unknown proxy type is only warned about once
+			_, err = db.LookupIPV4Dot("1.0.0.2")
+			Expect(err).To(BeNil())
+			Expect(buf.String()).To(Equal("ip2proxy: 1.0.0.1 unknown proxy type \"XYZ\"\n"))
+		})
+		It("should not log anything by default", func() {
+			var buf bytes.Buffer
+			log.SetOutput(&buf)
+			defer log.SetOutput(os.Stderr)
+			data, err := ioutil.ReadFile(filepath.Join("testdata", "PX11-SAMPLE.BIN"))
+			Expect(err).To(BeNil())
+			binary.LittleEndian.PutUint32(data[21:], 0)
+			_, err = FromBytes(data)
+			Expect(err).To(BeNil())
+			_, err = FromBytes(data, WithLogger(nil))
+			Expect(err).To(BeNil())
+			Expect(buf.String()).To(BeEmpty())
+		})
+	})
+	Context("when reading a db without index", func() {
+		It("should search all the rows", func() {
+			data, err := ioutil.ReadFile(filepath.Join("testdata", "PX11-SAMPLE.BIN"))
+			Expect(err).To(BeNil())
+			binary.LittleEndian.PutUint32(data[21:], 0)
+			// garbage where the index was
+			for i := 64; i < 64+65536*8; i++ {
+				data[i] = 0xFF
+			}
+			for _, opts := range [][]Option{nil, {WithLazyIndex()}} {
+				db, err := FromBytes(data, opts...)
+				Expect(err).To(BeNil())
+				Expect(db.Verify()).To(Succeed())
+				for ip, expected := range map[string]ProxyType{
+					"0.0.0.0":         ProxyNOT,
+					"1.0.0.255":       ProxyVPN,
+					"1.0.1.0":         ProxyTOR,
+					"3.0.0.128":       ProxyPUB,
+					"4.0.0.2":         ProxyNOT,
+					"255.255.255.255": ProxyVPN,
+				} {
+					res, err := db.LookupIPV4Dot(ip)
+					Expect(err).To(BeNil())
+					Expect(res.Proxy).To(Equal(expected), ip)
+				}
+			}
+		})
+	})
+	Context("when loading indexes lazily", func() {
+		db, err := Open(filepath.Join("testdata", "IP2PROXY-LITE-PX4.BIN"), WithLazyIndex())
+		if err != nil {
+			Fail("Loading IP2PROXY-LITE-PX4.BIN lazily should not have failed", 1)
+		}
+		It("should return the valid header infos", func() {
+			Expect(db.Version()).To(Equal("PX4-2018-02-01"))
+			Expect(db.Count()).To(Equal(uint32(3445221)))
+		})
+		It("should return a valid info for proxy hosts", func() {
+			list := map[string]ProxyType{
+				"78.220.10.108": ProxyNOT,
+				"8.8.8.8":       ProxyDCH,
+				"2.7.154.188":   ProxyTOR,
+				"1.0.194.42":    ProxyVPN,
+			}
+			for ip, expected := range list {
+				res, err := db.LookupIPV4Dot(ip)
+				Expect(res).ToNot(BeNil())
+				Expect(err).To(BeNil())
+				Expect(res.Proxy).To(Equal(expected))
+			}
+		})
+	})
+	Context("when looking up reserved addresses", func() {
+		It("should detect reserved ips", func() {
+			list := map[string]bool{
+				"10.1.2.3":        true,
+				"172.16.0.1":      true,
+				"192.168.1.1":     true,
+				"127.0.0.1":       true,
+				"169.254.1.1":     true,
+				"224.0.0.1":       true,
+				"0.0.0.0":         true,
+				"255.255.255.255": true,
+				"100.64.0.1":      true,
+				"100.127.255.255": true,
+				"192.0.2.1":       true,
+				"198.51.100.1":    true,
+				"203.0.113.1":     true,
+				"240.0.0.1":       true,
+				"::1":             true,
+				"fc00::1":         true,
+				"8.8.8.8":         false,
+				"2.7.154.188":     false,
+				"100.128.0.1":     false,
+				"192.0.3.1":       false,
+				"2001:4860::8888": false,
+			}
+			for ip, expected := range list {
+				Expect(IsReserved(net.ParseIP(ip))).To(Equal(expected), ip)
+			}
+		})
+		It("should return ErrReserved without a reserved result", func() {
+			db, err := Open(filepath.Join("testdata", "IP2PROXY-LITE-PX4.BIN"), WithReserved(nil))
+			Expect(err).To(BeNil())
+			res, err := db.LookupIPV4Dot("192.168.1.1")
+			Expect(res).To(BeNil())
+			Expect(err).To(Equal(ErrReserved))
+			res, err = db.LookupIPV4Dot("8.8.8.8")
+			Expect(err).To(BeNil())
+			Expect(res.Proxy).To(Equal(ProxyDCH))
+		})
+		It("should return a copy of the reserved result", func() {
+			reserved := &Result{Proxy: ProxyNOT}
+			db, err := Open(filepath.Join("testdata", "IP2PROXY-LITE-PX4.BIN"), WithReserved(reserved))
+			Expect(err).To(BeNil())
+			res, err := db.LookupIPV4Dot("10.0.0.1")
+			Expect(err).To(BeNil())
+			Expect(res).ToNot(BeIdenticalTo(reserved))
+			Expect(res.IP).To(Equal("10.0.0.1"))
+			Expect(res.Proxy).To(Equal(ProxyNOT))
+			Expect(res.Reserved).To(BeTrue())
+			Expect(reserved.IP).To(Equal(""))
+			Expect(reserved.Reserved).To(BeFalse())
+		})
+		It("should flag the results of reserved addrs", func() {
+			db, err := Open(filepath.Join("testdata", "IP2PROXY-LITE-PX4.BIN"), WithReservedFlag())
+			Expect(err).To(BeNil())
+			res, err := db.LookupIPV4Dot("10.0.0.1")
+			Expect(err).To(BeNil())
+			Expect(res.Reserved).To(BeTrue())
+			Expect(res.Proxy).To(Equal(ProxyNOT))
+			res, err = db.LookupIPV4Dot("8.8.8.8")
+			Expect(err).To(BeNil())
+			Expect(res.Reserved).To(BeFalse())
+			db, err = Open(filepath.Join("testdata", "IP2PROXY-LITE-PX4.BIN"))
+			Expect(err).To(BeNil())
+			res, err = db.LookupIPV4Dot("10.0.0.1")
+			Expect(err).To(BeNil())
+			Expect(res.Reserved).To(BeFalse())
+		})
 	})
 })
@@ -0,0 +1,32 @@
+package ip2proxy
+
+import "sync/atomic"
+
+// the default db, holding a defaultHolder
+var defaultDB atomic.Value
+
+// holds the default db, as atomic.Value cannot store nil
+type defaultHolder struct {
+	db *DB
+}
+
+// SetDefault sets the db returned by DefaultDB and used by DefaultLookup, nil unsetting it. It is safe for
+// concurrent use, so that the default db can be replaced by a newer version while lookups are running.
+func SetDefault(db *DB) {
+	defaultDB.Store(defaultHolder{db: db})
+}
+
+// DefaultDB returns the db set by SetDefault, nil if unset
+func DefaultDB() *DB {
+	h, _ := defaultDB.Load().(defaultHolder)
+	return h.db
+}
+
+// DefaultLookup lookups a dot notation (1.2.3.4) ipv4 address in the default db, returning ErrNoDefault if unset
+func DefaultLookup(ip string) (*Result, error) {
+	db := DefaultDB()
+	if db == nil {
+		return nil, ErrNoDefault
+	}
+	return db.LookupIPV4Dot(ip)
+}
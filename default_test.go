@@ -0,0 +1,30 @@
+package ip2proxy_test
+
+import (
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/etf1/ip2proxy"
+)
+
+var _ = Describe("Default", func() {
+	AfterEach(func() {
+		SetDefault(nil)
+	})
+	It("should return an error when unset", func() {
+		Expect(DefaultDB()).To(BeNil())
+		_, err := DefaultLookup("1.0.0.1")
+		Expect(err).To(Equal(ErrNoDefault))
+	})
+	It("should lookup in the default db", func() {
+		db, err := Open(filepath.Join("testdata", "PX11-SAMPLE.BIN"))
+		Expect(err).To(BeNil())
+		SetDefault(db)
+		Expect(DefaultDB()).To(Equal(db))
+		res, err := DefaultLookup("1.0.0.1")
+		Expect(err).To(BeNil())
+		Expect(res.Proxy).To(Equal(ProxyVPN))
+	})
+})
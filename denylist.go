@@ -0,0 +1,105 @@
+package ip2proxy
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/juju/errors"
+)
+
+// DenylistFormat is a format of denylist written by ExportDenylist
+type DenylistFormat uint8
+
+const (
+	// DenylistCIDR writes a CIDR per line
+	DenylistCIDR DenylistFormat = iota
+	// DenylistIptables writes an iptables rule dropping the input packets from each CIDR, as read by iptables-restore
+	DenylistIptables
+	// DenylistNginx writes a nginx deny directive per CIDR
+	DenylistNginx
+)
+
+// denylist lines formats, by denylist format
+var denylistLines = map[DenylistFormat]string{
+	DenylistCIDR:     "%s\n",
+	DenylistIptables: "-A INPUT -s %s -j DROP\n",
+	DenylistNginx:    "deny %s;\n",
+}
+
+// ExportDenylist writes to w the CIDRs of the proxy records of the db in format, adjacent records being merged into
+// the smallest list of CIDRs covering them. Only the records of types are written, or the records of all the known
+// proxy types when types is empty. The proxies expired by WithMaxLastSeen are left out. It fails for a db without
+// proxy type.
+func (db *DB) ExportDenylist(w io.Writer, format DenylistFormat, types ...ProxyType) error {
+	line, ok := denylistLines[format]
+	if !ok {
+		return fmt.Errorf("unknown denylist format %d", format)
+	}
+	buf := bufio.NewWriter(w)
+	err := db.proxyRanges(types, func(r Range) {
+		for _, cidr := range rangeCIDRs(r.From, r.To) {
+			fmt.Fprintf(buf, line, cidr)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return errors.Annotate(buf.Flush(), "cannot write denylist")
+}
+
+// RangesAsCIDRs returns the CIDRs of the proxy records of the db, as ExportDenylist writes them, in ascending addrs
+// order. Only the records of types are returned, or the records of all the proxy types when types is empty. It fails
+// for a db without proxy type.
+func (db *DB) RangesAsCIDRs(types ...ProxyType) ([]net.IPNet, error) {
+	var cidrs []net.IPNet
+	err := db.proxyRanges(types, func(r Range) {
+		cidrs = append(cidrs, r.CIDRs()...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cidrs, nil
+}
+
+// calls fn with the ranges of the proxy records of types, or of all the proxy types when types is empty, adjacent
+// records being merged in a single range
+func (db *DB) proxyRanges(types []ProxyType, fn func(r Range)) error {
+	if !db.HasField(FieldProxy) {
+		return fmt.Errorf("%s db has no %s field", db.TypeName(), FieldProxy)
+	}
+	match := db.exported
+	if len(types) > 0 {
+		match = func(res *Result) bool {
+			for _, t := range types {
+				if res.Proxy == t {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	var pending *Range
+	err := db.Iterate(func(res *Result) error {
+		if !match(res) {
+			return nil
+		}
+		if pending != nil && pending.To+1 == res.RangeFrom {
+			pending.To = res.RangeTo
+			return nil
+		}
+		if pending != nil {
+			fn(*pending)
+		}
+		pending = &Range{From: res.RangeFrom, To: res.RangeTo}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if pending != nil {
+		fn(*pending)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package ip2proxy_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/etf1/ip2proxy"
+)
+
+var _ = Describe("Denylist", func() {
+	var db *DB
+	BeforeEach(func() {
+		var err error
+		db, err = Open(filepath.Join("testdata", "PX11-SAMPLE.BIN"))
+		Expect(err).To(BeNil())
+	})
+	It("should write the CIDRs of the proxy records", func() {
+		var buf bytes.Buffer
+		Expect(db.ExportDenylist(&buf, DenylistCIDR)).To(Succeed())
+		Expect(buf.String()).To(Equal(
+			"1.0.0.0/24\n1.0.1.0/32\n2.0.0.0/24\n3.0.0.0/24\n4.0.0.0/32\n255.255.255.0/24\n",
+		))
+	})
+	It("should only write the records of the proxy types", func() {
+		var buf bytes.Buffer
+		Expect(db.ExportDenylist(&buf, DenylistIptables, ProxyVPN, ProxyWEB)).To(Succeed())
+		Expect(buf.String()).To(Equal(
+			"-A INPUT -s 1.0.0.0/24 -j DROP\n-A INPUT -s 4.0.0.0/32 -j DROP\n-A INPUT -s 255.255.255.0/24 -j DROP\n",
+		))
+		buf.Reset()
+		Expect(db.ExportDenylist(&buf, DenylistNginx, ProxyTOR)).To(Succeed())
+		Expect(buf.String()).To(Equal("deny 1.0.1.0/32;\n"))
+	})
+	It("should leave out the proxies last seen too long ago", func() {
+		expiringDB, err := Open(filepath.Join("testdata", "PX11-SAMPLE.BIN"), WithMaxLastSeen(10))
+		Expect(err).To(BeNil())
+		var buf bytes.Buffer
+		Expect(expiringDB.ExportDenylist(&buf, DenylistCIDR)).To(Succeed())
+		// the DCH record at 2.0.0.0 and the WEB record at 4.0.0.0 were last seen 30 and 12 days ago
+		Expect(buf.String()).To(Equal("1.0.0.0/24\n1.0.1.0/32\n3.0.0.0/24\n255.255.255.0/24\n"))
+		buf.Reset()
+		Expect(expiringDB.ExportCSV(&buf)).To(Succeed())
+		Expect(strings.Count(buf.String(), "\r\n")).To(Equal(4))
+		Expect(buf.String()).NotTo(ContainSubstring(`"DCH","US"`))
+		Expect(buf.String()).NotTo(ContainSubstring(`"WEB"`))
+	})
+	It("should split unaligned ranges into CIDRs", func() {
+		b, err := NewBuilder(PX2, time.Now())
+		Expect(err).To(BeNil())
+		Expect(b.Add(dotToInt("10.0.0.1"), dotToInt("10.0.0.6"), &Result{Proxy: ProxyPUB})).To(Succeed())
+		Expect(b.Add(dotToInt("10.0.0.7"), dotToInt("10.0.0.9"), &Result{Proxy: ProxyVPN})).To(Succeed())
+		data, err := b.Bytes()
+		Expect(err).To(BeNil())
+		db, err := FromBytes(data)
+		Expect(err).To(BeNil())
+		var buf bytes.Buffer
+		Expect(db.ExportDenylist(&buf, DenylistCIDR)).To(Succeed())
+		Expect(buf.String()).To(Equal("10.0.0.1/32\n10.0.0.2/31\n10.0.0.4/30\n10.0.0.8/31\n"))
+	})
+	It("should return the CIDRs of the proxy records", func() {
+		cidrs, err := db.RangesAsCIDRs(ProxyVPN, ProxyWEB)
+		Expect(err).To(BeNil())
+		var strs []string
+		for _, cidr := range cidrs {
+			strs = append(strs, cidr.String())
+		}
+		Expect(strs).To(Equal([]string{"1.0.0.0/24", "4.0.0.0/32", "255.255.255.0/24"}))
+		cidrs, err = db.RangesAsCIDRs()
+		Expect(err).To(BeNil())
+		Expect(cidrs).To(HaveLen(6))
+		lite, err := NewBuilder(PX1, time.Now())
+		Expect(err).To(BeNil())
+		data, err := lite.Bytes()
+		Expect(err).To(BeNil())
+		px1, err := FromBytes(data)
+		Expect(err).To(BeNil())
+		_, err = px1.RangesAsCIDRs()
+		Expect(err).To(MatchError("PX1 db has no proxy field"))
+	})
+	It("should return an error for an unknown format", func() {
+		Expect(db.ExportDenylist(&bytes.Buffer{}, DenylistFormat(42))).To(MatchError("unknown denylist format 42"))
+	})
+})
@@ -0,0 +1,191 @@
+package ip2proxy
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// DiffOption configures a diff of dbs
+type DiffOption func(*diffOptions)
+
+// diff options
+type diffOptions struct {
+	jsonl      bool
+	proxyTypes bool
+}
+
+// DiffJSONL makes DiffStream write a json object per change line rather than human readable lines
+func DiffJSONL() DiffOption {
+	return func(o *diffOptions) {
+		o.jsonl = true
+	}
+}
+
+// ProxyChangesOnly makes DiffStream only write the changes of proxy type, leaving out the other fields changes
+func ProxyChangesOnly() DiffOption {
+	return func(o *diffOptions) {
+		o.proxyTypes = true
+	}
+}
+
+// kinds of changes
+const (
+	changeAdded        = "added"
+	changeRemoved      = "removed"
+	changeReclassified = "reclassified"
+	changeFields       = "changed"
+)
+
+// a change of the records of an addrs range
+type change struct {
+	Kind     string    `json:"kind"`
+	From     string    `json:"from"`
+	To       string    `json:"to"`
+	OldProxy ProxyType `json:"old_proxy"`
+	NewProxy ProxyType `json:"new_proxy"`
+	Fields   []string  `json:"fields,omitempty"`
+}
+
+// DiffStream compares the ipv4 records of oldDB and newDB in a single pass over both, writing to w a change line per
+// addrs range which records differ, in ascending addrs order. The kinds of changes are:
+//   - added: a proxy range which was not a proxy one in oldDB
+//   - removed: a range which was a proxy one in oldDB and is not anymore
+//   - reclassified: a proxy range which proxy type changed
+//   - changed: a range which proxy type is unchanged but other fields values changed, which are listed
+//
+// A range not covered by a db has the N/A proxy type in it. Lines are human readable unless DiffJSONL is set.
+func DiffStream(oldDB, newDB *DB, w io.Writer, opts ...DiffOption) error {
+	var o diffOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	buf := bufio.NewWriter(w)
+	enc := json.NewEncoder(buf)
+	write := func(c *change) error {
+		if o.jsonl {
+			return enc.Encode(c)
+		}
+		line := fmt.Sprintf("%s-%s %s: %s -> %s", c.From, c.To, c.Kind, c.OldProxy, c.NewProxy)
+		if len(c.Fields) > 0 {
+			line += " (" + strings.Join(c.Fields, ", ") + ")"
+		}
+		_, err := buf.WriteString(line + "\n")
+		return err
+	}
+
+	oldRecords, newRecords := &recordCursor{db: oldDB}, &recordCursor{db: newDB}
+	for pos := uint64(0); pos <= math.MaxUint32; {
+		a, err := oldRecords.from(uint32(pos))
+		if err != nil {
+			return errors.Annotate(err, "cannot read old db")
+		}
+		b, err := newRecords.from(uint32(pos))
+		if err != nil {
+			return errors.Annotate(err, "cannot read new db")
+		}
+		if a == nil && b == nil {
+			break
+		}
+		// the records covering pos, and the end of the segment both hold
+		end := uint32(math.MaxUint32)
+		var oldRes, newRes *Result
+		for _, r := range []struct {
+			res *Result
+			cur **Result
+		}{{a, &oldRes}, {b, &newRes}} {
+			switch {
+			case r.res == nil:
+			case uint64(r.res.RangeFrom) <= pos:
+				*r.cur = r.res
+				end = min32(end, r.res.RangeTo)
+			default:
+				end = min32(end, r.res.RangeFrom-1)
+			}
+		}
+		if c := diffRecords(oldRes, newRes, o.proxyTypes); c != nil {
+			c.From, c.To = intToIPV4(uint32(pos)), intToIPV4(end)
+			if err := write(c); err != nil {
+				return errors.Annotate(err, "cannot write diff")
+			}
+		}
+		pos = uint64(end) + 1
+	}
+	return errors.Annotate(buf.Flush(), "cannot write diff")
+}
+
+// gets the change between the old and current records of a range, nil ones when not covered, or nil when unchanged
+func diffRecords(old, cur *Result, proxyTypes bool) *change {
+	c := &change{OldProxy: ProxyNA, NewProxy: ProxyNA}
+	if old != nil {
+		c.OldProxy = old.Proxy
+	}
+	if cur != nil {
+		c.NewProxy = cur.Proxy
+	}
+	wasProxy, isProxy := isProxyType(c.OldProxy), isProxyType(c.NewProxy)
+	switch {
+	case !wasProxy && isProxy:
+		c.Kind = changeAdded
+	case wasProxy && !isProxy:
+		c.Kind = changeRemoved
+	case c.OldProxy != c.NewProxy && wasProxy:
+		c.Kind = changeReclassified
+	case proxyTypes || old == nil || cur == nil:
+		return nil
+	default:
+		diff := old.Diff(cur)
+		for f := FieldCountry; f <= lastField; f++ {
+			if _, ok := diff[f]; ok && f != FieldProxy && f != FieldRawProxy && f != FieldCountryRaw &&
+				f != FieldISPCanonical {
+				c.Fields = append(c.Fields, f.String())
+			}
+		}
+		if len(c.Fields) == 0 {
+			return nil
+		}
+		c.Kind = changeFields
+	}
+	return c
+}
+
+// checks if t is the type of a proxy
+func isProxyType(t ProxyType) bool {
+	return t != ProxyNA && t != ProxyNOT
+}
+
+// walks the records of a db in ascending addrs order
+type recordCursor struct {
+	db  *DB
+	row uint32
+	res *Result
+}
+
+// gets the first record ending at pos or after, nil when there is none
+func (c *recordCursor) from(pos uint32) (*Result, error) {
+	for c.res == nil || c.res.RangeTo < pos {
+		if c.row+1 >= c.db.header.Count {
+			return nil, nil
+		}
+		from, to, err := c.db.rowRange(c.row)
+		if err != nil {
+			return nil, err
+		}
+		c.row++
+		if from > to || to < pos {
+			continue
+		}
+		res, err := c.db.readIPV4Record(recordOffset(c.db.ipv4(), c.row-1))
+		if err != nil {
+			return nil, errors.Annotatef(err, "cannot read record %d", c.row-1)
+		}
+		res.RangeFrom, res.RangeTo = from, to
+		c.res = res
+	}
+	return c.res, nil
+}
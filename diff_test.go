@@ -0,0 +1,63 @@
+package ip2proxy_test
+
+import (
+	"bytes"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/etf1/ip2proxy"
+)
+
+var _ = Describe("DiffStream", func() {
+	date := time.Date(2021, time.Month(6), 1, 0, 0, 0, 0, time.UTC)
+	build := func(records map[[2]string]*Result) *DB {
+		b, err := NewBuilder(PX4, date)
+		Expect(err).To(BeNil())
+		for r, res := range records {
+			Expect(b.Add(dotToInt(r[0]), dotToInt(r[1]), res)).To(Succeed())
+		}
+		data, err := b.Bytes()
+		Expect(err).To(BeNil())
+		db, err := FromBytes(data)
+		Expect(err).To(BeNil())
+		return db
+	}
+	var oldDB, newDB *DB
+	BeforeEach(func() {
+		ptrStr := func(str string) *string { return &str }
+		oldDB = build(map[[2]string]*Result{
+			{"1.0.0.0", "1.0.0.255"}: {Proxy: ProxyVPN, ISP: ptrStr("Some VPN")},
+			{"2.0.0.0", "2.0.0.255"}: {Proxy: ProxyTOR},
+			{"3.0.0.0", "3.0.0.255"}: {Proxy: ProxyPUB},
+		})
+		newDB = build(map[[2]string]*Result{
+			{"1.0.0.0", "1.0.0.255"}: {Proxy: ProxyVPN, ISP: ptrStr("Other VPN")},
+			{"2.0.0.0", "2.0.0.127"}: {Proxy: ProxyDCH},
+			{"4.0.0.0", "4.0.0.255"}: {Proxy: ProxyWEB},
+		})
+	})
+	It("should write the changed ranges", func() {
+		var buf bytes.Buffer
+		Expect(DiffStream(oldDB, newDB, &buf)).To(Succeed())
+		Expect(buf.String()).To(Equal(`1.0.0.0-1.0.0.255 changed: VPN -> VPN (isp)
+2.0.0.0-2.0.0.127 reclassified: TOR -> DCH
+2.0.0.128-2.0.0.255 removed: TOR -> NOT
+3.0.0.0-3.0.0.255 removed: PUB -> NOT
+4.0.0.0-4.0.0.255 added: NOT -> WEB
+`))
+	})
+	It("should only write the proxy type changes as json lines", func() {
+		var buf bytes.Buffer
+		Expect(DiffStream(oldDB, newDB, &buf, DiffJSONL(), ProxyChangesOnly())).To(Succeed())
+		lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+		Expect(lines).To(HaveLen(4))
+		Expect(string(lines[0])).To(Equal(`{"kind":"reclassified","from":"2.0.0.0","to":"2.0.0.127","old_proxy":"TOR","new_proxy":"DCH"}`))
+	})
+	It("should write nothing for identical dbs", func() {
+		var buf bytes.Buffer
+		Expect(DiffStream(oldDB, oldDB, &buf)).To(Succeed())
+		Expect(buf.Len()).To(BeZero())
+	})
+})
@@ -0,0 +1,69 @@
+package ip2proxy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+// OpenLatest opens the most recent db file of the directory dir, according to the date of their header. Only the
+// .BIN files are considered, the first one by name being opened when several share the most recent date. Only the
+// header of the other files is read. The unreadable or corrupt files are skipped with a warning to the logger set by
+// WithLogger, the next most recent file being opened instead, and an error is returned when no file is valid.
+func OpenLatest(dir string, opts ...Option) (*DB, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot read db directory")
+	}
+	o := options{logger: nopLogger{}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	type candidate struct {
+		path string
+		date time.Time
+	}
+	var candidates []candidate
+	found := false
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".bin") {
+			continue
+		}
+		found = true
+		path := filepath.Join(dir, entry.Name())
+		date, err := readFileDate(path)
+		if err != nil {
+			o.logger.Printf("ip2proxy: %s skipped: %s", path, err)
+			continue
+		}
+		candidates = append(candidates, candidate{path, date})
+	}
+	if !found {
+		return nil, fmt.Errorf("no .BIN file in %s", dir)
+	}
+	// the most recent first, the entries being sorted by name
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].date.After(candidates[j].date) })
+	for _, c := range candidates {
+		db, err := Open(c.path, opts...)
+		if err != nil {
+			o.logger.Printf("ip2proxy: %s skipped: %s", c.path, err)
+			continue
+		}
+		return db, nil
+	}
+	return nil, fmt.Errorf("no valid .BIN file in %s", dir)
+}
+
+// reads the date in the header of the db file at path
+func readFileDate(path string) (time.Time, error) {
+	db, err := OpenHeaderOnly(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return db.Date(), nil
+}
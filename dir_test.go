@@ -0,0 +1,79 @@
+package ip2proxy_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/etf1/ip2proxy"
+)
+
+var _ = Describe("Dir", func() {
+	var dir string
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "ip2proxy")
+		Expect(err).To(BeNil())
+	})
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+	// writes a db dated date to the file name of dir
+	writeDB := func(name string, date time.Time) {
+		b, err := NewBuilder(PX2, date)
+		Expect(err).To(BeNil())
+		data, err := b.Bytes()
+		Expect(err).To(BeNil())
+		Expect(ioutil.WriteFile(filepath.Join(dir, name), data, 0644)).To(Succeed())
+	}
+
+	It("should open the most recent db", func() {
+		writeDB("IP2PROXY-PX2-2021-07.BIN", time.Date(2021, time.Month(7), 1, 0, 0, 0, 0, time.UTC))
+		writeDB("IP2PROXY-PX2-2021-09.bin", time.Date(2021, time.Month(9), 1, 0, 0, 0, 0, time.UTC))
+		writeDB("IP2PROXY-PX2-2021-08.BIN", time.Date(2021, time.Month(8), 1, 0, 0, 0, 0, time.UTC))
+		writeDB("IP2PROXY-PX2-2021-10.BIN.tmp", time.Date(2021, time.Month(10), 1, 0, 0, 0, 0, time.UTC))
+		Expect(os.Mkdir(filepath.Join(dir, "archive.BIN"), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "README.txt"), []byte("readme"), 0644)).To(Succeed())
+		db, err := OpenLatest(dir)
+		Expect(err).To(BeNil())
+		Expect(db.Version()).To(Equal("PX2-2021-09-01"))
+	})
+	It("should return an error for a directory without db", func() {
+		Expect(ioutil.WriteFile(filepath.Join(dir, "README.txt"), []byte("readme"), 0644)).To(Succeed())
+		_, err := OpenLatest(dir)
+		Expect(err).To(MatchError("no .BIN file in " + dir))
+	})
+	It("should skip the invalid db files", func() {
+		writeDB("IP2PROXY-PX2-2021-07.BIN", time.Date(2021, time.Month(7), 1, 0, 0, 0, 0, time.UTC))
+		writeDB("IP2PROXY-PX2-2021-09.BIN", time.Date(2021, time.Month(9), 1, 0, 0, 0, 0, time.UTC))
+		// the most recent file truncated after its index start
+		path := filepath.Join(dir, "IP2PROXY-PX2-2021-09.BIN")
+		data, err := ioutil.ReadFile(path)
+		Expect(err).To(BeNil())
+		Expect(ioutil.WriteFile(path, data[:2048], 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "IP2PROXY.BIN"), []byte("PX"), 0644)).To(Succeed())
+		var logs bytes.Buffer
+		db, err := OpenLatest(dir, WithLogger(log.New(&logs, "", 0)))
+		Expect(err).To(BeNil())
+		Expect(db.Version()).To(Equal("PX2-2021-07-01"))
+		Expect(strings.Split(logs.String(), "\n")).To(Equal([]string{
+			"ip2proxy: " + path + " skipped: cannot read db header: invalid db format: 2 records of 12 bytes at offset " +
+				"524353 end at 524376, beyond file size 2048",
+			"ip2proxy: " + filepath.Join(dir, "IP2PROXY.BIN") + " skipped: " + filepath.Join(dir, "IP2PROXY.BIN") +
+				" is empty or too small",
+			"",
+		}))
+	})
+	It("should return an error for a directory without valid db", func() {
+		Expect(ioutil.WriteFile(filepath.Join(dir, "IP2PROXY.BIN"), []byte("PX"), 0644)).To(Succeed())
+		_, err := OpenLatest(dir)
+		Expect(err).To(MatchError("no valid .BIN file in " + dir))
+	})
+})
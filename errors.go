@@ -0,0 +1,27 @@
+package ip2proxy
+
+import "github.com/juju/errors"
+
+var (
+	// ErrReserved is returned when looking up a reserved address on a db opened WithReserved(nil)
+	ErrReserved = errors.New("reserved IP")
+	// ErrTooManyResults is returned when a range lookup exceeds the maximum count of results, see WithMaxResults
+	ErrTooManyResults = errors.New("too many results")
+	// ErrNoDefault is returned by DefaultLookup when no default db is set
+	ErrNoDefault = errors.New("no default db")
+	// ErrExpired is the cause of the error returned when opening a db older than the maximum age set by WithMaxAge
+	ErrExpired = errors.New("db expired")
+	// ErrInvalidIP is returned when parsing or looking up an invalid address
+	ErrInvalidIP = errors.New("invalid IP")
+	// ErrUnclassified is the cause of the error returned when looking up an address of unknown proxy type on a db
+	// opened WithStrictProxy
+	ErrUnclassified = errors.New("unclassified proxy type")
+	// ErrChecksumMismatch is the cause of the error returned when opening a db which digest is not the one set by
+	// WithExpectedSHA256
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+	// ErrDateSkew is the cause of the error returned when creating a MultiDB of dbs which dates differ by more than the
+	// maximum set by WithMaxDateSkew
+	ErrDateSkew = errors.New("dbs date skew")
+	// ErrHeaderOnly is the cause of the error returned when looking up or iterating a db opened with OpenHeaderOnly
+	ErrHeaderOnly = errors.New("db header only")
+)
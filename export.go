@@ -0,0 +1,222 @@
+package ip2proxy
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/juju/errors"
+)
+
+// ExportOption configures an export
+type ExportOption func(*exportOptions)
+
+// export options
+type exportOptions struct {
+	coalesce bool
+	workers  int
+}
+
+// number of rows decoded at once by an export worker
+const exportChunkRows = 1024
+
+// CoalesceAdjacent merges the adjacent records holding the same fields values into a single wider record
+func CoalesceAdjacent() ExportOption {
+	return func(o *exportOptions) {
+		o.coalesce = true
+	}
+}
+
+// ParallelDecode makes the export decode the records in workers goroutines, writing them in the same order as a
+// sequential export. A value of 1 or less decodes them sequentially.
+func ParallelDecode(workers int) ExportOption {
+	return func(o *exportOptions) {
+		o.workers = workers
+	}
+}
+
+// ExportCSV writes the records of the db to w as a IP2Proxy csv file, which BuildFromCSV reads back. As in the
+// IP2Proxy csv files, non proxy addrs are left out of dbs with a proxy type, proxies of unknown type or expired by
+// WithMaxLastSeen included, as well as addrs without country of PX1 dbs.
+func (db *DB) ExportCSV(w io.Writer, opts ...ExportOption) error {
+	columns := db.csvColumns()
+	buf := bufio.NewWriter(w)
+	err := db.export(opts, func(res *Result) error {
+		if !db.exported(res) {
+			return nil
+		}
+		buf.WriteString(quoteCSV(strconv.FormatUint(uint64(res.RangeFrom), 10)))
+		buf.WriteByte(',')
+		buf.WriteString(quoteCSV(strconv.FormatUint(uint64(res.RangeTo), 10)))
+		for _, f := range columns {
+			v := res.CountryRaw
+			if f != FieldCountry || v == nil {
+				v = *f.ptr(res)
+			}
+			buf.WriteByte(',')
+			buf.WriteString(quoteCSV(strOrDash(v)))
+		}
+		_, err := buf.WriteString("\r\n")
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return errors.Annotate(buf.Flush(), "cannot write csv")
+}
+
+// ExportJSONL writes each record of the db to w as a json line: its json encoding, with the RangeFrom and RangeTo of
+// the addrs range it holds and its IP set to the first one. Unlike ExportCSV, non proxy records are exported.
+func (db *DB) ExportJSONL(w io.Writer, opts ...ExportOption) error {
+	jsonl := newJSONLWriter(w)
+	err := db.export(opts, func(res *Result) error {
+		return jsonl.write(res, nil)
+	})
+	if err != nil {
+		return err
+	}
+	return errors.Annotate(jsonl.flush(), "cannot write json lines")
+}
+
+// checks if a record is exported: a proxy record, not expired by WithMaxLastSeen, or, in dbs without proxy type, a
+// record with a country
+func (db *DB) exported(res *Result) bool {
+	if db.positions.Proxy != 0 {
+		return res.Proxy != ProxyNOT && res.Proxy != ProxyNA
+	}
+	return res.CountryCode != nil || res.Country != nil
+}
+
+// calls fn for each record of the db according to the export options
+func (db *DB) export(opts []ExportOption, fn func(res *Result) error) error {
+	var o exportOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	iterate := db.Iterate
+	if o.workers > 1 {
+		iterate = func(fn func(res *Result) error) error {
+			return db.iterateParallel(o.workers, fn)
+		}
+	}
+	if !o.coalesce {
+		return iterate(fn)
+	}
+	var pending *Result
+	err := iterate(func(res *Result) error {
+		if pending != nil && pending.RangeTo+1 == res.RangeFrom && pending.Equal(res) {
+			pending.RangeTo = res.RangeTo
+			return nil
+		}
+		if pending != nil {
+			if err := fn(pending); err != nil {
+				return err
+			}
+		}
+		pending = res
+		return nil
+	})
+	if err != nil || pending == nil {
+		return err
+	}
+	return fn(pending)
+}
+
+// calls fn for each record of the db in ascending addrs order as Iterate does, the records being decoded by workers
+// goroutines in chunks of rows. At most two chunks per worker are decoded or waiting for their turn.
+func (db *DB) iterateParallel(workers int, fn func(res *Result) error) error {
+	if err := db.checkRecords(); err != nil {
+		return err
+	}
+	type chunk struct {
+		results []*Result
+		err     error
+	}
+	type job struct {
+		from, to uint32
+		out      chan chunk
+	}
+	rows := db.header.Count - 1
+	jobs := make(chan job, 2*workers)
+	// the chunks outputs in rows order
+	pending := make(chan chan chunk, 2*workers)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer close(done)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(pending)
+		defer close(jobs)
+		for from := uint32(0); from < rows; from += exportChunkRows {
+			j := job{from: from, to: from + min32(exportChunkRows, rows-from), out: make(chan chunk, 1)}
+			select {
+			case pending <- j.out:
+			case <-done:
+				return
+			}
+			select {
+			case jobs <- j:
+			case <-done:
+				return
+			}
+		}
+	}()
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				var c chunk
+				for i := j.from; i < j.to && c.err == nil; i++ {
+					var res *Result
+					if res, c.err = db.readRow(i); res != nil {
+						c.results = append(c.results, res)
+					}
+				}
+				j.out <- c
+			}
+		}()
+	}
+	for out := range pending {
+		c := <-out
+		if c.err != nil {
+			return c.err
+		}
+		for _, res := range c.results {
+			if err := fn(res); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// gets the fields of the csv columns of the db, following the ip_from and ip_to columns
+func (db *DB) csvColumns() []Field {
+	var fields []Field
+	for col := uint8(2); col <= db.header.Cols; col++ {
+		switch fieldPosition(col) {
+		case db.positions.Proxy:
+			fields = append(fields, FieldRawProxy)
+		case db.positions.Country:
+			fields = append(fields, FieldCountryCode, FieldCountry)
+		default:
+			for f := FieldCity; f <= FieldProvider; f++ {
+				if f != FieldProxy && *db.positions.field(f) == fieldPosition(col) {
+					fields = append(fields, f)
+				}
+			}
+		}
+	}
+	return fields
+}
+
+// quotes a csv field
+func quoteCSV(s string) string {
+	return `"` + strings.Replace(s, `"`, `""`, -1) + `"`
+}
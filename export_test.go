@@ -0,0 +1,167 @@
+package ip2proxy_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/etf1/ip2proxy"
+)
+
+var _ = Describe("Export", func() {
+	date := time.Date(2021, time.Month(6), 1, 0, 0, 0, 0, time.UTC)
+	// gets all the records of a db
+	records := func(db *DB) []*Result {
+		var results []*Result
+		Expect(db.Iterate(func(res *Result) error {
+			results = append(results, res)
+			return nil
+		})).To(Succeed())
+		return results
+	}
+
+	It("should export the sample db csv", func() {
+		db, err := Open(filepath.Join("testdata", "PX11-SAMPLE.BIN"))
+		Expect(err).To(BeNil())
+		var buf bytes.Buffer
+		Expect(db.ExportCSV(&buf)).To(Succeed())
+		Expect(strings.Count(buf.String(), "\r\n")).To(Equal(6))
+		Expect(buf.String()).To(HavePrefix(
+			`"16777216","16777471","VPN","AU","Australia","Queensland","Brisbane","Sample VPN Ltd","samplevpn.example",` +
+				`"DCH","13335","Sample VPN AS","7","-","SampleVPN"` + "\r\n",
+		))
+
+		var rebuilt bytes.Buffer
+		Expect(BuildFromCSV(&buf, &rebuilt, PX11, date)).To(Succeed())
+		rebuiltDB, err := FromBytes(rebuilt.Bytes())
+		Expect(err).To(BeNil())
+		expected := records(db)
+		actual := records(rebuiltDB)
+		Expect(actual).To(HaveLen(len(expected)))
+		for i := range expected {
+			Expect(actual[i].Equal(expected[i])).To(BeTrue(), expected[i].IP)
+			Expect(actual[i].RangeFrom).To(Equal(expected[i].RangeFrom))
+			Expect(actual[i].RangeTo).To(Equal(expected[i].RangeTo))
+		}
+	})
+	It("should export the csv the sample db was built from", func() {
+		csv, err := ioutil.ReadFile(filepath.Join("testdata", "PX11-SAMPLE.csv"))
+		Expect(err).To(BeNil())
+		var data bytes.Buffer
+		Expect(BuildFromCSV(bytes.NewReader(csv), &data, PX11, date)).To(Succeed())
+		db, err := FromBytes(data.Bytes())
+		Expect(err).To(BeNil())
+		var buf bytes.Buffer
+		Expect(db.ExportCSV(&buf)).To(Succeed())
+		// the single addr records, as 16777472 and 67108864, keep their range, and empty fields are exported as -
+		expected := strings.NewReplacer("\n", "\r\n", `,""`, `,"-"`).Replace(string(csv))
+		Expect(buf.String()).To(Equal(expected))
+	})
+	It("should coalesce adjacent records", func() {
+		csv := strings.Join([]string{
+			`"16777216","16777471","VPN","AU","Australia"`,
+			`"16777472","16777727","VPN","AU","Australia"`,
+			`"16777728","16777983","VPN","FR","France"`,
+			`"16777984","16778239","VPN","FR","France"`,
+			`"16778496","16778751","VPN","FR","France"`,
+		}, "\n")
+		var data bytes.Buffer
+		Expect(BuildFromCSV(strings.NewReader(csv), &data, PX2, date)).To(Succeed())
+		db, err := FromBytes(data.Bytes())
+		Expect(err).To(BeNil())
+
+		var buf bytes.Buffer
+		Expect(db.ExportCSV(&buf)).To(Succeed())
+		Expect(strings.Count(buf.String(), "\r\n")).To(Equal(5))
+		buf.Reset()
+		Expect(db.ExportCSV(&buf, CoalesceAdjacent())).To(Succeed())
+		Expect(buf.String()).To(Equal(strings.Join([]string{
+			`"16777216","16777727","VPN","AU","Australia"`,
+			`"16777728","16778239","VPN","FR","France"`,
+			`"16778496","16778751","VPN","FR","France"`,
+			``,
+		}, "\r\n")))
+	})
+	It("should export the sample db json lines", func() {
+		db, err := Open(filepath.Join("testdata", "PX11-SAMPLE.BIN"))
+		Expect(err).To(BeNil())
+		var buf bytes.Buffer
+		Expect(db.ExportJSONL(&buf)).To(Succeed())
+		expected := records(db)
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		Expect(lines).To(HaveLen(len(expected)))
+		for i, line := range lines {
+			var res Result
+			Expect(json.Unmarshal([]byte(line), &res)).To(Succeed())
+			Expect(&res).To(Equal(expected[i]))
+		}
+		Expect(lines[1]).To(HavePrefix(`{"schema_version":1,"ip":"1.0.0.0","country":"Australia",`))
+		Expect(lines[1]).To(ContainSubstring(`"range_from":16777216,"range_to":16777471,`))
+	})
+	It("should coalesce adjacent records of json lines", func() {
+		csv := strings.Join([]string{
+			`"16777216","16777471","VPN","AU","Australia"`,
+			`"16777472","16777727","VPN","AU","Australia"`,
+		}, "\n")
+		var data bytes.Buffer
+		Expect(BuildFromCSV(strings.NewReader(csv), &data, PX2, date)).To(Succeed())
+		db, err := FromBytes(data.Bytes())
+		Expect(err).To(BeNil())
+		var buf bytes.Buffer
+		Expect(db.ExportJSONL(&buf, CoalesceAdjacent())).To(Succeed())
+		Expect(strings.Split(buf.String(), "\n")).To(ConsistOf(
+			ContainSubstring(`"proxy":"NOT","raw_proxy":"-","range_to":16777215,`),
+			ContainSubstring(`"proxy":"VPN","raw_proxy":"VPN","range_from":16777216,"range_to":16777727,`),
+			ContainSubstring(`"proxy":"NOT","raw_proxy":"-","range_from":16777728,"range_to":4294967295,`),
+			BeEmpty(),
+		))
+	})
+	It("should export the countries of a db without proxy type", func() {
+		b, err := NewBuilder(PX1, date)
+		Expect(err).To(BeNil())
+		code, name := "FR", `"France"`
+		Expect(b.Add(16777216, 16777471, &Result{CountryCode: &code, Country: &name})).To(Succeed())
+		data, err := b.Bytes()
+		Expect(err).To(BeNil())
+		db, err := FromBytes(data)
+		Expect(err).To(BeNil())
+		var buf bytes.Buffer
+		Expect(db.ExportCSV(&buf)).To(Succeed())
+		Expect(buf.String()).To(Equal(`"16777216","16777471","FR","""France"""` + "\r\n"))
+	})
+	It("should export the lite db", func() {
+		db, err := Open(filepath.Join("testdata", "IP2PROXY-LITE-PX4.BIN"))
+		Expect(err).To(BeNil())
+		var buf, coalesced bytes.Buffer
+		Expect(db.ExportCSV(&buf)).To(Succeed())
+		Expect(db.ExportCSV(&coalesced, CoalesceAdjacent())).To(Succeed())
+		Expect(coalesced.Len()).To(BeNumerically("<", buf.Len()))
+		Expect(coalesced.String()).To(ContainSubstring(`"PUB","FR","France"`))
+
+		var parallel bytes.Buffer
+		Expect(db.ExportCSV(&parallel, ParallelDecode(4))).To(Succeed())
+		Expect(parallel.String()).To(Equal(buf.String()))
+		parallel.Reset()
+		Expect(db.ExportCSV(&parallel, ParallelDecode(3), CoalesceAdjacent())).To(Succeed())
+		Expect(parallel.String()).To(Equal(coalesced.String()))
+	})
+	It("should stop a parallel export at the first write error", func() {
+		db, err := Open(filepath.Join("testdata", "IP2PROXY-LITE-PX4.BIN"))
+		Expect(err).To(BeNil())
+		Expect(db.ExportJSONL(failingWriter{}, ParallelDecode(4))).To(MatchError("write failed"))
+	})
+})
+
+// a writer failing all writes
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write failed")
+}
@@ -0,0 +1,133 @@
+package ip2proxy
+
+import (
+	"io"
+	"math"
+	"net"
+)
+
+// uint128 holds an addr of any family, ipv4 addrs being held in lo
+type uint128 struct {
+	hi, lo uint64
+}
+
+// compares a and b, returning -1, 0 or 1
+func (a uint128) cmp(b uint128) int {
+	switch {
+	case a.hi < b.hi || a.hi == b.hi && a.lo < b.lo:
+		return -1
+	case a == b:
+		return 0
+	}
+	return 1
+}
+
+// The header addrs of the sections and indexes (BaseAddr, IndexBaseAddr, IPv6BaseAddr, IPv6IndexAddr) are 1-based
+// file addrs, as in the IP2Proxy specification, 0 meaning absent. All the other offsets are 0-based byte offsets in the
+// file: the rows and records offsets, computed from the header addrs once checked, the fields positions in records
+// and the strings offsets stored in fields.
+
+// addrFamily is the section of the db holding the records of an addrs family. The families share the rows search
+// and the records decoding, only differing by the width of their addrs and by their index.
+type addrFamily interface {
+	// gets the index bucket of ip, as its first and last rows
+	bucket(ip uint128) (uint32, uint32, error)
+	// gets the number of rows, the last one only holding the end of the previous record
+	rows() uint32
+	// gets the byte offset of the row i
+	rowOffset(i uint32) uint32
+	// reads the addr leading the row at byte offset off
+	readAddr(off uint32) (uint128, error)
+	// gets the width in bytes of the addrs leading the rows
+	width() uint32
+	// gets the highest addr of the family
+	maxAddr() uint128
+}
+
+// the ipv4 section
+type ipv4Family struct {
+	db *DB
+}
+
+func (f ipv4Family) bucket(ip uint128) (uint32, uint32, error) {
+	return f.db.ipv4Index(uint32(ip.lo >> 16))
+}
+
+func (f ipv4Family) rows() uint32 {
+	return f.db.header.Count
+}
+
+func (f ipv4Family) rowOffset(i uint32) uint32 {
+	return f.db.rowOffset(i)
+}
+
+func (f ipv4Family) readAddr(off uint32) (uint128, error) {
+	ip, err := f.db.readUint32(off)
+	return uint128{lo: uint64(ip)}, err
+}
+
+func (f ipv4Family) width() uint32 {
+	return net.IPv4len
+}
+
+func (f ipv4Family) maxAddr() uint128 {
+	return uint128{lo: math.MaxUint32}
+}
+
+// the ipv6 section
+type ipv6Family struct {
+	db *DB
+}
+
+func (f ipv6Family) bucket(ip uint128) (uint32, uint32, error) {
+	return f.db.ipv6Index(uint32(ip.hi >> 48))
+}
+
+func (f ipv6Family) rows() uint32 {
+	return f.db.header.IPv6Count
+}
+
+func (f ipv6Family) rowOffset(i uint32) uint32 {
+	return f.db.header.IPv6BaseAddr - 1 + i*uint32(f.db.header.IPv6ColumnSize)
+}
+
+func (f ipv6Family) readAddr(off uint32) (uint128, error) {
+	if uint64(off)+net.IPv6len > uint64(f.db.dataSize) {
+		return uint128{}, io.EOF
+	}
+	b := f.db.data[off : off+net.IPv6len]
+	return uint128{hi: fileEndianness.Uint64(b[8:]), lo: fileEndianness.Uint64(b)}, nil
+}
+
+func (f ipv6Family) width() uint32 {
+	return net.IPv6len
+}
+
+func (f ipv6Family) maxAddr() uint128 {
+	return uint128{hi: math.MaxUint64, lo: math.MaxUint64}
+}
+
+// gets the ipv4 section
+func (db *DB) ipv4() addrFamily {
+	return ipv4Family{db}
+}
+
+// gets the ipv6 section
+func (db *DB) ipv6() addrFamily {
+	return ipv6Family{db}
+}
+
+// gets the byte offset of the record held by the row i of the family f, from which the fields are read at their
+// positions: the positions count the leading addr as an ipv4 one
+func recordOffset(f addrFamily, i uint32) uint32 {
+	return f.rowOffset(i) + f.width() - net.IPv4len
+}
+
+// converts the 1-based file addr plus delta bytes to a byte offset, ok being false when addr is 0 or the offset is
+// beyond the 4GB addressable
+func fileOffset(addr uint32, delta uint64) (off uint32, ok bool) {
+	if addr == 0 || uint64(addr)-1+delta > math.MaxUint32 {
+		return 0, false
+	}
+	return uint32(uint64(addr) - 1 + delta), true
+}
@@ -0,0 +1,219 @@
+package ip2proxy
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Field is a result field
+type Field uint8
+
+const (
+	// FieldCountry is the country name field
+	FieldCountry Field = iota + 1
+	// FieldCountryCode is the country code field
+	FieldCountryCode
+	// FieldCity is the city field
+	FieldCity
+	// FieldISP is the ISP field
+	FieldISP
+	// FieldRegion is the region field
+	FieldRegion
+	// FieldProxy is the proxy type field, its values being the proxy type names
+	FieldProxy
+	// FieldDomain is the domain field
+	FieldDomain
+	// FieldUsageType is the usage type field
+	FieldUsageType
+	// FieldASN is the autonomous system number field
+	FieldASN
+	// FieldAS is the autonomous system name field
+	FieldAS
+	// FieldLastSeen is the last seen field
+	FieldLastSeen
+	// FieldThreat is the threat field
+	FieldThreat
+	// FieldProvider is the provider field
+	FieldProvider
+	// FieldRawProxy is the proxy type field as stored in db
+	FieldRawProxy
+	// FieldCountryRaw is the country name field as stored in db, when the country name is normalized
+	FieldCountryRaw
+	// FieldISPCanonical is the canonical form of the ISP field, when the ISP is canonicalized
+	FieldISPCanonical
+
+	// last field, for iterations over all fields
+	lastField = FieldISPCanonical
+)
+
+// fields json names
+var fieldNames = map[Field]string{
+	FieldCountry:      "country",
+	FieldCountryCode:  "country_code",
+	FieldCity:         "city",
+	FieldISP:          "isp",
+	FieldRegion:       "region",
+	FieldProxy:        "proxy",
+	FieldDomain:       "domain",
+	FieldUsageType:    "usage_type",
+	FieldASN:          "asn",
+	FieldAS:           "as",
+	FieldLastSeen:     "last_seen",
+	FieldThreat:       "threat",
+	FieldProvider:     "provider",
+	FieldRawProxy:     "raw_proxy",
+	FieldCountryRaw:   "country_raw",
+	FieldISPCanonical: "isp_canonical",
+}
+
+// fields in the order of their standard columns, with their column by db type
+var standardColumns = []struct {
+	field Field
+	pos   []uint8
+}{
+	{FieldProxy, proxytypePos},
+	{FieldCountryCode, countryPos},
+	{FieldCountry, countryPos},
+	{FieldRegion, regionPos},
+	{FieldCity, cityPos},
+	{FieldISP, ispPos},
+	{FieldDomain, domainPos},
+	{FieldUsageType, usageTypePos},
+	{FieldASN, asnPos},
+	{FieldAS, asPos},
+	{FieldLastSeen, lastSeenPos},
+	{FieldThreat, threatPos},
+	{FieldProvider, providerPos},
+}
+
+// Fields returns the fields held by the db type t, in the order of their columns as in the IP2Proxy csv files, the
+// country code coming before the country name. It returns nil for an unknown type.
+func (t DbType) Fields() []Field {
+	if t < PX1 || t > PX11 {
+		return nil
+	}
+	var fields []Field
+	for _, c := range standardColumns {
+		if c.pos[t] != 0 {
+			fields = append(fields, c.field)
+		}
+	}
+	return fields
+}
+
+// String returns the field json name
+func (f Field) String() string {
+	if name, ok := fieldNames[f]; ok {
+		return name
+	}
+	return "N/A"
+}
+
+// gets the field value in r, ok is false when it is absent
+func (f Field) value(r *Result) (string, bool) {
+	if f == FieldProxy {
+		return r.Proxy.String(), r.Proxy != ProxyNA
+	}
+	p := f.ptr(r)
+	if p == nil || *p == nil {
+		return "", false
+	}
+	return **p, true
+}
+
+// gets a copy of the field value in r, nil when absent or r is nil
+func (f Field) valuePtr(r *Result) *string {
+	if r == nil {
+		return nil
+	}
+	v, ok := f.value(r)
+	if !ok {
+		return nil
+	}
+	return &v
+}
+
+// gets the string field f of r, nil for the proxy type field and unknown fields
+func (f Field) ptr(r *Result) **string {
+	switch f {
+	case FieldCountry:
+		return &r.Country
+	case FieldCountryCode:
+		return &r.CountryCode
+	case FieldCity:
+		return &r.City
+	case FieldISP:
+		return &r.ISP
+	case FieldRegion:
+		return &r.Region
+	case FieldDomain:
+		return &r.Domain
+	case FieldUsageType:
+		return &r.UsageType
+	case FieldASN:
+		return &r.ASN
+	case FieldAS:
+		return &r.AS
+	case FieldLastSeen:
+		return &r.LastSeen
+	case FieldThreat:
+		return &r.Threat
+	case FieldProvider:
+		return &r.Provider
+	case FieldRawProxy:
+		return &r.RawProxy
+	case FieldCountryRaw:
+		return &r.CountryRaw
+	case FieldISPCanonical:
+		return &r.ISPCanonical
+	}
+	return nil
+}
+
+// HasField checks if the db type holds the field f
+func (db *DB) HasField(f Field) bool {
+	pos := db.positions.field(f)
+	return pos != nil && *pos != 0
+}
+
+// Strings returns the distinct values of the field f among all records, sorted
+func (db *DB) Strings(f Field) ([]string, error) {
+	seen, err := db.distinct(f)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]string, 0, len(seen))
+	for s := range seen {
+		values = append(values, s)
+	}
+	sort.Strings(values)
+	return values, nil
+}
+
+// CountDistinct returns the number of distinct values of the field f among all records, as the length of Strings
+// without building and sorting the values list
+func (db *DB) CountDistinct(f Field) (int, error) {
+	seen, err := db.distinct(f)
+	if err != nil {
+		return 0, err
+	}
+	return len(seen), nil
+}
+
+// gets the set of the distinct values of the field f among all records
+func (db *DB) distinct(f Field) (map[string]struct{}, error) {
+	if !db.HasField(f) {
+		return nil, fmt.Errorf("%s db has no %s field", db.TypeName(), f)
+	}
+	seen := map[string]struct{}{}
+	err := db.Iterate(func(res *Result) error {
+		if s, ok := f.value(res); ok {
+			seen[s] = struct{}{}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return seen, nil
+}
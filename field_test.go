@@ -0,0 +1,55 @@
+package ip2proxy_test
+
+import (
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/etf1/ip2proxy"
+)
+
+var _ = Describe("Field", func() {
+	var db *DB
+	BeforeEach(func() {
+		var err error
+		db, err = Open(filepath.Join("testdata", "PX11-SAMPLE.BIN"))
+		Expect(err).To(BeNil())
+	})
+	It("should return the field names", func() {
+		Expect(FieldCountryCode.String()).To(Equal("country_code"))
+		Expect(FieldUsageType.String()).To(Equal("usage_type"))
+		Expect(Field(0).String()).To(Equal("N/A"))
+	})
+	It("should return the distinct values of a field", func() {
+		values, err := db.Strings(FieldUsageType)
+		Expect(err).To(BeNil())
+		Expect(values).To(Equal([]string{"COM", "DCH", "ISP/MOB"}))
+		values, err = db.Strings(FieldProxy)
+		Expect(err).To(BeNil())
+		Expect(values).To(Equal([]string{"DCH", "NOT", "PUB", "TOR", "VPN", "WEB"}))
+		values, err = db.Strings(FieldCountryCode)
+		Expect(err).To(BeNil())
+		Expect(values).To(Equal([]string{"AU", "DE", "FR", "GB", "NL", "US"}))
+		count, err := db.CountDistinct(FieldCountryCode)
+		Expect(err).To(BeNil())
+		Expect(count).To(Equal(6))
+	})
+	It("should return the fields of a db type in their columns order", func() {
+		Expect(PX1.Fields()).To(Equal([]Field{FieldCountryCode, FieldCountry}))
+		Expect(PX4.Fields()).To(Equal([]Field{FieldProxy, FieldCountryCode, FieldCountry, FieldRegion, FieldCity, FieldISP}))
+		Expect(PX11.Fields()).To(HaveLen(13))
+		Expect(PX11.Fields()[12]).To(Equal(FieldProvider))
+		Expect(UnknownDbType.Fields()).To(BeNil())
+	})
+	It("should return an error for a field not held by the db", func() {
+		db, err := Open(filepath.Join("testdata", "IP2PROXY-LITE-PX4.BIN"))
+		Expect(err).To(BeNil())
+		Expect(db.HasField(FieldISP)).To(BeTrue())
+		Expect(db.HasField(FieldDomain)).To(BeFalse())
+		_, err = db.Strings(FieldDomain)
+		Expect(err).To(MatchError("PX4 db has no domain field"))
+		_, err = db.CountDistinct(FieldDomain)
+		Expect(err).To(MatchError("PX4 db has no domain field"))
+	})
+})
@@ -0,0 +1,53 @@
+package ip2proxy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FindByISP returns the ranges of the records which ISP contains substr, in ascending addrs order. The match is case
+// insensitive when caseInsensitive is set. It reads all the records of the db, and fails for a db without ISP field.
+func (db *DB) FindByISP(substr string, caseInsensitive bool) ([]Range, error) {
+	if !db.HasField(FieldISP) {
+		return nil, fmt.Errorf("%s db has no %s field", db.TypeName(), FieldISP)
+	}
+	match := func(isp string) bool { return strings.Contains(isp, substr) }
+	if caseInsensitive {
+		lower := strings.ToLower(substr)
+		match = func(isp string) bool { return strings.Contains(strings.ToLower(isp), lower) }
+	}
+	return db.find(func(res *Result) bool {
+		return res.ISP != nil && match(*res.ISP)
+	})
+}
+
+// FindByASN returns the ranges of the records of the autonomous system number asn, in ascending addrs order. It reads
+// all the records of the db, and fails for a db without ASN field.
+func (db *DB) FindByASN(asn uint32) ([]Range, error) {
+	if !db.HasField(FieldASN) {
+		return nil, fmt.Errorf("%s db has no %s field", db.TypeName(), FieldASN)
+	}
+	return db.find(func(res *Result) bool {
+		if res.ASN == nil {
+			return false
+		}
+		n, err := strconv.ParseUint(*res.ASN, 10, 32)
+		return err == nil && uint32(n) == asn
+	})
+}
+
+// gets the ranges of the records matching match, in ascending addrs order
+func (db *DB) find(match func(res *Result) bool) ([]Range, error) {
+	var ranges []Range
+	err := db.Iterate(func(res *Result) error {
+		if match(res) {
+			ranges = append(ranges, Range{From: res.RangeFrom, To: res.RangeTo, Result: res})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ranges, nil
+}
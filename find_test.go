@@ -0,0 +1,72 @@
+package ip2proxy_test
+
+import (
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/etf1/ip2proxy"
+)
+
+var _ = Describe("Find", func() {
+	var db *DB
+	BeforeEach(func() {
+		var err error
+		db, err = Open(filepath.Join("testdata", "PX11-SAMPLE.BIN"))
+		Expect(err).To(BeNil())
+	})
+	Context("when searching by ISP", func() {
+		It("should return the ranges of the matching records", func() {
+			ranges, err := db.FindByISP("Proxy", false)
+			Expect(err).To(BeNil())
+			Expect(ranges).To(HaveLen(2))
+			Expect(ranges[0].From).To(Equal(dotToInt("3.0.0.0")))
+			Expect(ranges[0].To).To(Equal(dotToInt("3.0.0.255")))
+			Expect(*ranges[0].Result.ISP).To(Equal("Open Proxy ISP"))
+			Expect(ranges[1].From).To(Equal(dotToInt("4.0.0.0")))
+			Expect(ranges[1].To).To(Equal(dotToInt("4.0.0.0")))
+			Expect(*ranges[1].Result.ISP).To(Equal("Web Proxy Inc"))
+		})
+		It("should match case insensitively", func() {
+			ranges, err := db.FindByISP("vpn", false)
+			Expect(err).To(BeNil())
+			Expect(ranges).To(BeEmpty())
+			ranges, err = db.FindByISP("vpn", true)
+			Expect(err).To(BeNil())
+			Expect(ranges).To(HaveLen(2))
+			Expect(*ranges[0].Result.ISP).To(Equal("Sample VPN Ltd"))
+			Expect(*ranges[1].Result.ISP).To(Equal("Last Range VPN"))
+		})
+		It("should return an error for a db without ISP", func() {
+			b, err := NewBuilder(PX3, time.Now())
+			Expect(err).To(BeNil())
+			data, err := b.Bytes()
+			Expect(err).To(BeNil())
+			db, err := FromBytes(data)
+			Expect(err).To(BeNil())
+			_, err = db.FindByISP("vpn", true)
+			Expect(err).To(MatchError("PX3 db has no isp field"))
+		})
+	})
+	Context("when searching by ASN", func() {
+		It("should return the ranges of the matching records", func() {
+			ranges, err := db.FindByASN(64502)
+			Expect(err).To(BeNil())
+			Expect(ranges).To(HaveLen(1))
+			Expect(ranges[0].From).To(Equal(dotToInt("4.0.0.0")))
+			Expect(ranges[0].To).To(Equal(dotToInt("4.0.0.0")))
+			Expect(*ranges[0].Result.AS).To(Equal("WEB-PROXY-INC"))
+			ranges, err = db.FindByASN(0)
+			Expect(err).To(BeNil())
+			Expect(ranges).To(BeEmpty())
+		})
+		It("should return an error for a db without ASN", func() {
+			db, err := Open(filepath.Join("testdata", "IP2PROXY-LITE-PX4.BIN"))
+			Expect(err).To(BeNil())
+			_, err = db.FindByASN(13335)
+			Expect(err).To(MatchError("PX4 db has no asn field"))
+		})
+	})
+})
@@ -0,0 +1,33 @@
+//go:build go1.18
+// +build go1.18
+
+package ip2proxy_test
+
+import (
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"testing"
+
+	. "github.com/etf1/ip2proxy"
+)
+
+func FuzzOpen(f *testing.F) {
+	sample, err := ioutil.ReadFile(filepath.Join("testdata", "PX11-SAMPLE.BIN"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(sample)
+	f.Add(sample[:2048])
+	f.Fuzz(func(t *testing.T, data []byte) {
+		db, err := FromBytes(data)
+		if err != nil {
+			return
+		}
+		for _, ip := range []uint32{0, 16777216, 33554687, 67108865, math.MaxUint32 - 1, math.MaxUint32} {
+			_, _ = db.LookupIPV4Num(ip)
+		}
+		_, _ = db.LookupAll(0, math.MaxUint32)
+		_ = db.Verify()
+	})
+}
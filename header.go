@@ -0,0 +1,69 @@
+package ip2proxy
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/juju/errors"
+)
+
+// size of the db file header, preceding the index
+const headerSize = 64
+
+// OpenHeaderOnly reads and checks the header of the db file at path, without reading its index nor its records. The
+// returned db only gives the header infos, such as Type, Version, Date or Count, its lookups and iterations returning
+// ErrHeaderOnly. It is meant to cheaply scan db files, e.g. to pick the newest one.
+func OpenHeaderOnly(path string) (*DB, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot open/read db file")
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot open/read db file")
+	}
+	if info.Size() < 1024 {
+		return nil, fmt.Errorf("%s is empty or too small", path)
+	}
+	if info.Size() > math.MaxUint32 {
+		return nil, fmt.Errorf("%s of %d bytes beyond the 4GB addressable by the db offsets", path, info.Size())
+	}
+	data := make([]byte, headerSize)
+	if _, err := io.ReadFull(f, data); err != nil {
+		return nil, errors.Annotate(err, "cannot open/read db file")
+	}
+	db := &DB{
+		data:       data,
+		dataSize:   headerSize,
+		fileSize:   uint32(info.Size()),
+		headerOnly: true,
+		opts:       options{logger: nopLogger{}},
+	}
+	if err := db.readHeader(); err != nil {
+		return nil, errors.Annotate(err, "cannot read db header")
+	}
+	db.source = Source{Type: db.Type(), Version: db.Version()}
+	if err := db.computePositions(); err != nil {
+		return nil, errors.Annotate(err, "invalid column layout")
+	}
+	return db, nil
+}
+
+// gets the size of the db file
+func (db *DB) size() uint32 {
+	if db.headerOnly {
+		return db.fileSize
+	}
+	return db.dataSize
+}
+
+// checks that the records of the db were read, returning ErrHeaderOnly when only its header was
+func (db *DB) checkRecords() error {
+	if db.headerOnly {
+		return errors.Annotatef(ErrHeaderOnly, "%s", db.Version())
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package ip2proxy_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/juju/errors"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/etf1/ip2proxy"
+)
+
+var _ = Describe("OpenHeaderOnly", func() {
+	It("should read the header infos", func() {
+		db, err := OpenHeaderOnly(filepath.Join("testdata", "PX11-SAMPLE.BIN"))
+		Expect(err).To(BeNil())
+		full, err := Open(filepath.Join("testdata", "PX11-SAMPLE.BIN"))
+		Expect(err).To(BeNil())
+		Expect(db.Type()).To(Equal(PX11))
+		Expect(db.Version()).To(Equal(full.Version()))
+		Expect(db.Date()).To(Equal(time.Date(2021, time.June, 1, 0, 0, 0, 0, time.Local)))
+		Expect(db.Count()).To(Equal(full.Count()))
+		Expect(db.HasField(FieldProvider)).To(BeTrue())
+	})
+	It("should not lookup nor iterate the records", func() {
+		db, err := OpenHeaderOnly(filepath.Join("testdata", "PX11-SAMPLE.BIN"))
+		Expect(err).To(BeNil())
+		_, err = db.LookupIPV4Dot("1.0.0.1")
+		Expect(errors.Cause(err)).To(Equal(ErrHeaderOnly))
+		_, err = db.LookupAll(0, 10)
+		Expect(errors.Cause(err)).To(Equal(ErrHeaderOnly))
+		err = db.Iterate(func(*Result) error { return nil })
+		Expect(errors.Cause(err)).To(Equal(ErrHeaderOnly))
+		_, _, err = db.IPv4Bounds()
+		Expect(errors.Cause(err)).To(Equal(ErrHeaderOnly))
+		Expect(errors.Cause(db.Verify())).To(Equal(ErrHeaderOnly))
+		Expect(errors.Cause(db.SaveIndex(ioutil.Discard))).To(Equal(ErrHeaderOnly))
+	})
+	It("should check the records fit in the file", func() {
+		data, err := ioutil.ReadFile(filepath.Join("testdata", "PX11-SAMPLE.BIN"))
+		Expect(err).To(BeNil())
+		f, err := ioutil.TempFile("", "ip2proxy")
+		Expect(err).To(BeNil())
+		defer os.Remove(f.Name())
+		_, err = f.Write(data[:len(data)-1024])
+		Expect(err).To(BeNil())
+		Expect(f.Close()).To(Succeed())
+		_, err = OpenHeaderOnly(f.Name())
+		Expect(err).To(MatchError(ContainSubstring("cannot read db header: invalid db format")))
+	})
+	It("should return an error for a missing file", func() {
+		_, err := OpenHeaderOnly(filepath.Join("testdata", "missing.BIN"))
+		Expect(err).To(MatchError(ContainSubstring("cannot open/read db file")))
+	})
+})
@@ -0,0 +1,24 @@
+// Package i18n localizes the ip2proxy results, using the CLDR data of golang.org/x/text. It is a separate package so
+// that the ip2proxy package does not depend on x/text.
+package i18n
+
+import (
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
+
+	"github.com/etf1/ip2proxy"
+)
+
+// CountryLocalized returns the name of the country of r in the language lang, according to its country code. It
+// returns an empty string when r has no country code, or when the code is not a known ISO 3166-1 one, as the "-" code
+// of the records without country. The english name stored in db is left in r.Country.
+func CountryLocalized(r *ip2proxy.Result, lang language.Tag) string {
+	if r == nil || r.CountryCode == nil {
+		return ""
+	}
+	region, err := language.ParseRegion(*r.CountryCode)
+	if err != nil {
+		return ""
+	}
+	return display.Regions(lang).Name(region)
+}
@@ -0,0 +1,13 @@
+package i18n_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestI18n(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "IP2Proxy I18n Suite")
+}
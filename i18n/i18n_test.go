@@ -0,0 +1,27 @@
+package i18n_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/text/language"
+
+	"github.com/etf1/ip2proxy"
+	. "github.com/etf1/ip2proxy/i18n"
+)
+
+var _ = Describe("CountryLocalized", func() {
+	It("should return the country name in the language", func() {
+		code, name := "DE", "Germany"
+		res := &ip2proxy.Result{CountryCode: &code, Country: &name}
+		Expect(CountryLocalized(res, language.French)).To(Equal("Allemagne"))
+		Expect(CountryLocalized(res, language.German)).To(Equal("Deutschland"))
+		Expect(CountryLocalized(res, language.English)).To(Equal("Germany"))
+		Expect(*res.Country).To(Equal("Germany"))
+	})
+	It("should return an empty name without known country code", func() {
+		Expect(CountryLocalized(&ip2proxy.Result{}, language.French)).To(BeEmpty())
+		code := "-"
+		Expect(CountryLocalized(&ip2proxy.Result{CountryCode: &code}, language.French)).To(BeEmpty())
+		Expect(CountryLocalized(nil, language.French)).To(BeEmpty())
+	})
+})
@@ -0,0 +1,114 @@
+package ip2proxy
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/juju/errors"
+)
+
+// saved index magic and version, see SaveIndex
+const (
+	savedIndexMagic   = "IP2PXIDX"
+	savedIndexVersion = 1
+)
+
+// SaveIndex writes the index buckets of the db to w, for WithSavedIndex to load them back when opening the same db
+// file rather than reading them from it. Only the index buckets are saved, the records and their strings being still
+// read from the db file. The saved index is tied to the db type, date, records counts and file size.
+func (db *DB) SaveIndex(w io.Writer) error {
+	if err := db.checkRecords(); err != nil {
+		return err
+	}
+	buf := bufio.NewWriter(w)
+	buf.Write(db.savedIndexHeader())
+	var bucket [8]byte
+	write := func(index func(i uint32) (uint32, uint32, error)) error {
+		for i := uint32(0); i < maxIndexes; i++ {
+			start, end, err := index(i)
+			if err != nil {
+				return err
+			}
+			fileEndianness.PutUint32(bucket[:], start)
+			fileEndianness.PutUint32(bucket[4:], end)
+			buf.Write(bucket[:])
+		}
+		return nil
+	}
+	if err := write(db.ipv4Index); err != nil {
+		return err
+	}
+	if db.header.IPv6Count > 0 {
+		if err := write(db.ipv6Index); err != nil {
+			return err
+		}
+	}
+	return errors.Annotate(buf.Flush(), "cannot write index")
+}
+
+// gets the header of the saved index, identifying the db
+func (db *DB) savedIndexHeader() []byte {
+	h := make([]byte, 0, 28)
+	h = append(h, savedIndexMagic...)
+	h = append(h, savedIndexVersion, uint8(db.header.Type), db.header.Month, db.header.Day)
+	var b [4]byte
+	for _, v := range []uint32{uint32(db.header.Year), db.header.Count, db.header.IPv6Count, db.dataSize} {
+		fileEndianness.PutUint32(b[:], v)
+		h = append(h, b[:]...)
+	}
+	return h
+}
+
+// loads the index buckets saved by SaveIndex from r
+func (db *DB) loadIndex(r io.Reader) error {
+	expected := db.savedIndexHeader()
+	h := make([]byte, len(expected))
+	if _, err := io.ReadFull(r, h); err != nil {
+		return errors.Annotate(err, "cannot read saved index")
+	}
+	if !bytes.Equal(h, expected) {
+		return fmt.Errorf("saved index of another db")
+	}
+	data := make([]byte, maxIndexes*8)
+	read := func(indexes *[maxIndexes][2]uint32, count uint32) error {
+		if _, err := io.ReadFull(r, data); err != nil {
+			return errors.Annotate(err, "cannot read saved index")
+		}
+		for i := range indexes {
+			start, end := fileEndianness.Uint32(data[i*8:]), fileEndianness.Uint32(data[i*8+4:])
+			if start > end || end > count {
+				return fmt.Errorf("invalid saved index bucket %d", i)
+			}
+			indexes[i] = [2]uint32{start, end}
+		}
+		return nil
+	}
+	var ipv4Indexes, ipv6Indexes [maxIndexes][2]uint32
+	if err := read(&ipv4Indexes, db.header.Count); err != nil {
+		return err
+	}
+	if db.header.IPv6Count > 0 {
+		if err := read(&ipv6Indexes, db.header.IPv6Count); err != nil {
+			return err
+		}
+	}
+	db.ipv4Indexes, db.ipv6Indexes = ipv4Indexes, ipv6Indexes
+	return nil
+}
+
+// reads the index buckets, from the saved index when set and matching the db
+func (db *DB) readIndexes() error {
+	if db.opts.savedIndex != nil {
+		err := db.loadIndex(db.opts.savedIndex)
+		if err == nil {
+			return nil
+		}
+		db.warnf("%s saved index not loaded, reading the db index: %s", db.Version(), err)
+	}
+	if err := db.readIPv4Indexes(); err != nil {
+		return errors.Annotate(err, "cannot read db index")
+	}
+	return errors.Annotate(db.readIPv6Indexes(), "cannot read db ipv6 index")
+}
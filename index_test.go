@@ -0,0 +1,56 @@
+package ip2proxy_test
+
+import (
+	"bytes"
+	"log"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/etf1/ip2proxy"
+)
+
+var _ = Describe("Saved index", func() {
+	var saved bytes.Buffer
+	BeforeEach(func() {
+		db, err := Open(filepath.Join("testdata", "PX11-SAMPLE.BIN"), WithLazyIndex())
+		Expect(err).To(BeNil())
+		saved.Reset()
+		Expect(db.SaveIndex(&saved)).To(Succeed())
+	})
+	It("should be loaded when opening the same db", func() {
+		var logs bytes.Buffer
+		db, err := Open(filepath.Join("testdata", "PX11-SAMPLE.BIN"), WithSavedIndex(&saved), WithLogger(log.New(&logs, "", 0)))
+		Expect(err).To(BeNil())
+		Expect(logs.Len()).To(BeZero())
+		Expect(saved.Len()).To(BeZero())
+		plain, err := Open(filepath.Join("testdata", "PX11-SAMPLE.BIN"))
+		Expect(err).To(BeNil())
+		for _, ip := range []string{"0.0.0.1", "1.0.0.1", "1.0.1.1", "3.0.0.1", "255.255.255.255"} {
+			expected, err := plain.LookupIPV4Dot(ip)
+			Expect(err).To(BeNil())
+			res, err := db.LookupIPV4Dot(ip)
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal(expected))
+		}
+	})
+	It("should be ignored when saved from another db", func() {
+		var logs bytes.Buffer
+		db, err := Open(filepath.Join("testdata", "IP2PROXY-LITE-PX4.BIN"), WithSavedIndex(&saved), WithLogger(log.New(&logs, "", 0)))
+		Expect(err).To(BeNil())
+		Expect(logs.String()).To(HavePrefix("ip2proxy: PX4-"))
+		Expect(logs.String()).To(HaveSuffix(" saved index not loaded, reading the db index: saved index of another db\n"))
+		res, err := db.LookupIPV4Dot("2.6.120.66")
+		Expect(err).To(BeNil())
+		Expect(res).NotTo(BeNil())
+	})
+	It("should be ignored when truncated", func() {
+		var logs bytes.Buffer
+		r := strings.NewReader(saved.String()[:1000])
+		_, err := Open(filepath.Join("testdata", "PX11-SAMPLE.BIN"), WithSavedIndex(r), WithLogger(log.New(&logs, "", 0)))
+		Expect(err).To(BeNil())
+		Expect(logs.String()).To(Equal("ip2proxy: PX11-2021-06-01 saved index not loaded, reading the db index: cannot read saved index: unexpected EOF\n"))
+	})
+})
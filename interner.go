@@ -0,0 +1,40 @@
+package ip2proxy
+
+import "sync"
+
+// Interner holds decoded strings so that identical strings share their memory. An interner can be shared by several
+// dbs using WithInterner and is safe for concurrent use. Its strings are never released.
+type Interner struct {
+	mu   sync.RWMutex
+	strs map[string]string
+}
+
+// NewInterner creates an empty interner
+func NewInterner() *Interner {
+	return &Interner{strs: map[string]string{}}
+}
+
+// Len returns the number of distinct strings held
+func (in *Interner) Len() int {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+	return len(in.strs)
+}
+
+// gets the held string equal to b, adding it if missing
+func (in *Interner) intern(b []byte) string {
+	in.mu.RLock()
+	s, ok := in.strs[string(b)]
+	in.mu.RUnlock()
+	if ok {
+		return s
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if s, ok := in.strs[string(b)]; ok {
+		return s
+	}
+	s = string(b)
+	in.strs[s] = s
+	return s
+}
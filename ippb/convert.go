@@ -0,0 +1,49 @@
+// Package ippb holds the protobuf message of the lookup results, for services exposing them over gRPC.
+//
+// The message is generated from result.proto, kept in a separate package so that the ip2proxy package does not
+// depend on protobuf.
+package ippb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative result.proto
+
+import (
+	"github.com/etf1/ip2proxy"
+)
+
+// FromResult converts a lookup result to its protobuf message, the fields absent from r are left unset.
+// It returns nil for a nil result.
+func FromResult(r *ip2proxy.Result) *Result {
+	if r == nil {
+		return nil
+	}
+	return &Result{
+		Ip:           r.IP,
+		Proxy:        ProxyType(r.Proxy),
+		Country:      copyStr(r.Country),
+		CountryCode:  copyStr(r.CountryCode),
+		City:         copyStr(r.City),
+		Isp:          copyStr(r.ISP),
+		Region:       copyStr(r.Region),
+		Domain:       copyStr(r.Domain),
+		UsageType:    copyStr(r.UsageType),
+		Asn:          copyStr(r.ASN),
+		As:           copyStr(r.AS),
+		LastSeen:     copyStr(r.LastSeen),
+		Threat:       copyStr(r.Threat),
+		Provider:     copyStr(r.Provider),
+		RawProxy:     copyStr(r.RawProxy),
+		CountryRaw:   copyStr(r.CountryRaw),
+		IspCanonical: copyStr(r.ISPCanonical),
+		RangeFrom:    r.RangeFrom,
+		RangeTo:      r.RangeTo,
+	}
+}
+
+// copies a string pointer, so that the message does not share its values with the result
+func copyStr(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	c := *s
+	return &c
+}
@@ -0,0 +1,52 @@
+package ippb_test
+
+import (
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/etf1/ip2proxy"
+	"github.com/etf1/ip2proxy/ippb"
+)
+
+var _ = Describe("FromResult", func() {
+	It("should map the proxy types", func() {
+		for t, expected := range map[ip2proxy.ProxyType]ippb.ProxyType{
+			ip2proxy.ProxyNA:  ippb.ProxyType_PROXY_TYPE_NA,
+			ip2proxy.ProxyNOT: ippb.ProxyType_PROXY_TYPE_NOT,
+			ip2proxy.ProxyVPN: ippb.ProxyType_PROXY_TYPE_VPN,
+			ip2proxy.ProxyTOR: ippb.ProxyType_PROXY_TYPE_TOR,
+			ip2proxy.ProxyDCH: ippb.ProxyType_PROXY_TYPE_DCH,
+			ip2proxy.ProxyPUB: ippb.ProxyType_PROXY_TYPE_PUB,
+			ip2proxy.ProxyWEB: ippb.ProxyType_PROXY_TYPE_WEB,
+			ip2proxy.ProxySES: ippb.ProxyType_PROXY_TYPE_SES,
+			ip2proxy.ProxyRES: ippb.ProxyType_PROXY_TYPE_RES,
+		} {
+			Expect(ippb.FromResult(&ip2proxy.Result{Proxy: t}).Proxy).To(Equal(expected), t.String())
+		}
+	})
+	It("should convert the lookup results", func() {
+		db, err := ip2proxy.Open(filepath.Join("..", "testdata", "PX11-SAMPLE.BIN"))
+		Expect(err).To(BeNil())
+		res, err := db.LookupIPV4Dot("1.0.1.0")
+		Expect(err).To(BeNil())
+		msg := ippb.FromResult(res)
+		Expect(msg.GetIp()).To(Equal("1.0.1.0"))
+		Expect(msg.GetProxy()).To(Equal(ippb.ProxyType_PROXY_TYPE_TOR))
+		Expect(msg.GetCountry()).To(Equal("Germany"))
+		Expect(msg.GetThreat()).To(Equal("SPAM"))
+		Expect(msg.Domain).To(BeNil())
+		Expect(msg.GetRangeFrom()).To(Equal(res.RangeFrom))
+
+		data, err := proto.Marshal(msg)
+		Expect(err).To(BeNil())
+		decoded := &ippb.Result{}
+		Expect(proto.Unmarshal(data, decoded)).To(Succeed())
+		Expect(proto.Equal(decoded, msg)).To(BeTrue())
+	})
+	It("should return nil for a nil result", func() {
+		Expect(ippb.FromResult(nil)).To(BeNil())
+	})
+})
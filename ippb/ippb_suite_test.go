@@ -0,0 +1,13 @@
+package ippb_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestIppb(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "IP2Proxy Protobuf Suite")
+}
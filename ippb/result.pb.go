@@ -0,0 +1,428 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.28.1
+// 	protoc        v3.21.12
+// source: result.proto
+
+package ippb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// ProxyType is the type of proxy detected
+type ProxyType int32
+
+const (
+	// hosts which proxy status is not available (db without the info or invalid data)
+	ProxyType_PROXY_TYPE_NA ProxyType = 0
+	// hosts not detected as proxy
+	ProxyType_PROXY_TYPE_NOT ProxyType = 1
+	// anonymizing VPN services
+	ProxyType_PROXY_TYPE_VPN ProxyType = 2
+	// Tor exit nodes
+	ProxyType_PROXY_TYPE_TOR ProxyType = 3
+	// hosting providers, data centers or content delivery networks
+	ProxyType_PROXY_TYPE_DCH ProxyType = 4
+	// public proxies
+	ProxyType_PROXY_TYPE_PUB ProxyType = 5
+	// web proxies
+	ProxyType_PROXY_TYPE_WEB ProxyType = 6
+	// search engine robots
+	ProxyType_PROXY_TYPE_SES ProxyType = 7
+	// residential proxies
+	ProxyType_PROXY_TYPE_RES ProxyType = 8
+)
+
+// Enum value maps for ProxyType.
+var (
+	ProxyType_name = map[int32]string{
+		0: "PROXY_TYPE_NA",
+		1: "PROXY_TYPE_NOT",
+		2: "PROXY_TYPE_VPN",
+		3: "PROXY_TYPE_TOR",
+		4: "PROXY_TYPE_DCH",
+		5: "PROXY_TYPE_PUB",
+		6: "PROXY_TYPE_WEB",
+		7: "PROXY_TYPE_SES",
+		8: "PROXY_TYPE_RES",
+	}
+	ProxyType_value = map[string]int32{
+		"PROXY_TYPE_NA":  0,
+		"PROXY_TYPE_NOT": 1,
+		"PROXY_TYPE_VPN": 2,
+		"PROXY_TYPE_TOR": 3,
+		"PROXY_TYPE_DCH": 4,
+		"PROXY_TYPE_PUB": 5,
+		"PROXY_TYPE_WEB": 6,
+		"PROXY_TYPE_SES": 7,
+		"PROXY_TYPE_RES": 8,
+	}
+)
+
+func (x ProxyType) Enum() *ProxyType {
+	p := new(ProxyType)
+	*p = x
+	return p
+}
+
+func (x ProxyType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ProxyType) Descriptor() protoreflect.EnumDescriptor {
+	return file_result_proto_enumTypes[0].Descriptor()
+}
+
+func (ProxyType) Type() protoreflect.EnumType {
+	return &file_result_proto_enumTypes[0]
+}
+
+func (x ProxyType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ProxyType.Descriptor instead.
+func (ProxyType) EnumDescriptor() ([]byte, []int) {
+	return file_result_proto_rawDescGZIP(), []int{0}
+}
+
+// Result holds the lookup results, the fields not held by the db are left unset
+type Result struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Ip           string    `protobuf:"bytes,1,opt,name=ip,proto3" json:"ip,omitempty"`
+	Proxy        ProxyType `protobuf:"varint,2,opt,name=proxy,proto3,enum=ip2proxy.ProxyType" json:"proxy,omitempty"`
+	Country      *string   `protobuf:"bytes,3,opt,name=country,proto3,oneof" json:"country,omitempty"`
+	CountryCode  *string   `protobuf:"bytes,4,opt,name=country_code,json=countryCode,proto3,oneof" json:"country_code,omitempty"`
+	City         *string   `protobuf:"bytes,5,opt,name=city,proto3,oneof" json:"city,omitempty"`
+	Isp          *string   `protobuf:"bytes,6,opt,name=isp,proto3,oneof" json:"isp,omitempty"`
+	Region       *string   `protobuf:"bytes,7,opt,name=region,proto3,oneof" json:"region,omitempty"`
+	Domain       *string   `protobuf:"bytes,8,opt,name=domain,proto3,oneof" json:"domain,omitempty"`
+	UsageType    *string   `protobuf:"bytes,9,opt,name=usage_type,json=usageType,proto3,oneof" json:"usage_type,omitempty"`
+	Asn          *string   `protobuf:"bytes,10,opt,name=asn,proto3,oneof" json:"asn,omitempty"`
+	As           *string   `protobuf:"bytes,11,opt,name=as,proto3,oneof" json:"as,omitempty"`
+	LastSeen     *string   `protobuf:"bytes,12,opt,name=last_seen,json=lastSeen,proto3,oneof" json:"last_seen,omitempty"`
+	Threat       *string   `protobuf:"bytes,13,opt,name=threat,proto3,oneof" json:"threat,omitempty"`
+	Provider     *string   `protobuf:"bytes,14,opt,name=provider,proto3,oneof" json:"provider,omitempty"`
+	RawProxy     *string   `protobuf:"bytes,15,opt,name=raw_proxy,json=rawProxy,proto3,oneof" json:"raw_proxy,omitempty"`
+	CountryRaw   *string   `protobuf:"bytes,16,opt,name=country_raw,json=countryRaw,proto3,oneof" json:"country_raw,omitempty"`
+	RangeFrom    uint32    `protobuf:"varint,17,opt,name=range_from,json=rangeFrom,proto3" json:"range_from,omitempty"`
+	RangeTo      uint32    `protobuf:"varint,18,opt,name=range_to,json=rangeTo,proto3" json:"range_to,omitempty"`
+	IspCanonical *string   `protobuf:"bytes,19,opt,name=isp_canonical,json=ispCanonical,proto3,oneof" json:"isp_canonical,omitempty"`
+}
+
+func (x *Result) Reset() {
+	*x = Result{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_result_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Result) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Result) ProtoMessage() {}
+
+func (x *Result) ProtoReflect() protoreflect.Message {
+	mi := &file_result_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Result.ProtoReflect.Descriptor instead.
+func (*Result) Descriptor() ([]byte, []int) {
+	return file_result_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Result) GetIp() string {
+	if x != nil {
+		return x.Ip
+	}
+	return ""
+}
+
+func (x *Result) GetProxy() ProxyType {
+	if x != nil {
+		return x.Proxy
+	}
+	return ProxyType_PROXY_TYPE_NA
+}
+
+func (x *Result) GetCountry() string {
+	if x != nil && x.Country != nil {
+		return *x.Country
+	}
+	return ""
+}
+
+func (x *Result) GetCountryCode() string {
+	if x != nil && x.CountryCode != nil {
+		return *x.CountryCode
+	}
+	return ""
+}
+
+func (x *Result) GetCity() string {
+	if x != nil && x.City != nil {
+		return *x.City
+	}
+	return ""
+}
+
+func (x *Result) GetIsp() string {
+	if x != nil && x.Isp != nil {
+		return *x.Isp
+	}
+	return ""
+}
+
+func (x *Result) GetRegion() string {
+	if x != nil && x.Region != nil {
+		return *x.Region
+	}
+	return ""
+}
+
+func (x *Result) GetDomain() string {
+	if x != nil && x.Domain != nil {
+		return *x.Domain
+	}
+	return ""
+}
+
+func (x *Result) GetUsageType() string {
+	if x != nil && x.UsageType != nil {
+		return *x.UsageType
+	}
+	return ""
+}
+
+func (x *Result) GetAsn() string {
+	if x != nil && x.Asn != nil {
+		return *x.Asn
+	}
+	return ""
+}
+
+func (x *Result) GetAs() string {
+	if x != nil && x.As != nil {
+		return *x.As
+	}
+	return ""
+}
+
+func (x *Result) GetLastSeen() string {
+	if x != nil && x.LastSeen != nil {
+		return *x.LastSeen
+	}
+	return ""
+}
+
+func (x *Result) GetThreat() string {
+	if x != nil && x.Threat != nil {
+		return *x.Threat
+	}
+	return ""
+}
+
+func (x *Result) GetProvider() string {
+	if x != nil && x.Provider != nil {
+		return *x.Provider
+	}
+	return ""
+}
+
+func (x *Result) GetRawProxy() string {
+	if x != nil && x.RawProxy != nil {
+		return *x.RawProxy
+	}
+	return ""
+}
+
+func (x *Result) GetCountryRaw() string {
+	if x != nil && x.CountryRaw != nil {
+		return *x.CountryRaw
+	}
+	return ""
+}
+
+func (x *Result) GetRangeFrom() uint32 {
+	if x != nil {
+		return x.RangeFrom
+	}
+	return 0
+}
+
+func (x *Result) GetRangeTo() uint32 {
+	if x != nil {
+		return x.RangeTo
+	}
+	return 0
+}
+
+func (x *Result) GetIspCanonical() string {
+	if x != nil && x.IspCanonical != nil {
+		return *x.IspCanonical
+	}
+	return ""
+}
+
+var File_result_proto protoreflect.FileDescriptor
+
+var file_result_proto_rawDesc = []byte{
+	0x0a, 0x0c, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x08,
+	0x69, 0x70, 0x32, 0x70, 0x72, 0x6f, 0x78, 0x79, 0x22, 0x88, 0x06, 0x0a, 0x06, 0x52, 0x65, 0x73,
+	0x75, 0x6c, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x02, 0x69, 0x70, 0x12, 0x29, 0x0a, 0x05, 0x70, 0x72, 0x6f, 0x78, 0x79, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0e, 0x32, 0x13, 0x2e, 0x69, 0x70, 0x32, 0x70, 0x72, 0x6f, 0x78, 0x79, 0x2e, 0x50, 0x72,
+	0x6f, 0x78, 0x79, 0x54, 0x79, 0x70, 0x65, 0x52, 0x05, 0x70, 0x72, 0x6f, 0x78, 0x79, 0x12, 0x1d,
+	0x0a, 0x07, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x72, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x48,
+	0x00, 0x52, 0x07, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x72, 0x79, 0x88, 0x01, 0x01, 0x12, 0x26, 0x0a,
+	0x0c, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x72, 0x79, 0x5f, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x09, 0x48, 0x01, 0x52, 0x0b, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x72, 0x79, 0x43, 0x6f,
+	0x64, 0x65, 0x88, 0x01, 0x01, 0x12, 0x17, 0x0a, 0x04, 0x63, 0x69, 0x74, 0x79, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x09, 0x48, 0x02, 0x52, 0x04, 0x63, 0x69, 0x74, 0x79, 0x88, 0x01, 0x01, 0x12, 0x15,
+	0x0a, 0x03, 0x69, 0x73, 0x70, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x48, 0x03, 0x52, 0x03, 0x69,
+	0x73, 0x70, 0x88, 0x01, 0x01, 0x12, 0x1b, 0x0a, 0x06, 0x72, 0x65, 0x67, 0x69, 0x6f, 0x6e, 0x18,
+	0x07, 0x20, 0x01, 0x28, 0x09, 0x48, 0x04, 0x52, 0x06, 0x72, 0x65, 0x67, 0x69, 0x6f, 0x6e, 0x88,
+	0x01, 0x01, 0x12, 0x1b, 0x0a, 0x06, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x18, 0x08, 0x20, 0x01,
+	0x28, 0x09, 0x48, 0x05, 0x52, 0x06, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x88, 0x01, 0x01, 0x12,
+	0x22, 0x0a, 0x0a, 0x75, 0x73, 0x61, 0x67, 0x65, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x09, 0x20,
+	0x01, 0x28, 0x09, 0x48, 0x06, 0x52, 0x09, 0x75, 0x73, 0x61, 0x67, 0x65, 0x54, 0x79, 0x70, 0x65,
+	0x88, 0x01, 0x01, 0x12, 0x15, 0x0a, 0x03, 0x61, 0x73, 0x6e, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09,
+	0x48, 0x07, 0x52, 0x03, 0x61, 0x73, 0x6e, 0x88, 0x01, 0x01, 0x12, 0x13, 0x0a, 0x02, 0x61, 0x73,
+	0x18, 0x0b, 0x20, 0x01, 0x28, 0x09, 0x48, 0x08, 0x52, 0x02, 0x61, 0x73, 0x88, 0x01, 0x01, 0x12,
+	0x20, 0x0a, 0x09, 0x6c, 0x61, 0x73, 0x74, 0x5f, 0x73, 0x65, 0x65, 0x6e, 0x18, 0x0c, 0x20, 0x01,
+	0x28, 0x09, 0x48, 0x09, 0x52, 0x08, 0x6c, 0x61, 0x73, 0x74, 0x53, 0x65, 0x65, 0x6e, 0x88, 0x01,
+	0x01, 0x12, 0x1b, 0x0a, 0x06, 0x74, 0x68, 0x72, 0x65, 0x61, 0x74, 0x18, 0x0d, 0x20, 0x01, 0x28,
+	0x09, 0x48, 0x0a, 0x52, 0x06, 0x74, 0x68, 0x72, 0x65, 0x61, 0x74, 0x88, 0x01, 0x01, 0x12, 0x1f,
+	0x0a, 0x08, 0x70, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65, 0x72, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x09,
+	0x48, 0x0b, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65, 0x72, 0x88, 0x01, 0x01, 0x12,
+	0x20, 0x0a, 0x09, 0x72, 0x61, 0x77, 0x5f, 0x70, 0x72, 0x6f, 0x78, 0x79, 0x18, 0x0f, 0x20, 0x01,
+	0x28, 0x09, 0x48, 0x0c, 0x52, 0x08, 0x72, 0x61, 0x77, 0x50, 0x72, 0x6f, 0x78, 0x79, 0x88, 0x01,
+	0x01, 0x12, 0x24, 0x0a, 0x0b, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x72, 0x79, 0x5f, 0x72, 0x61, 0x77,
+	0x18, 0x10, 0x20, 0x01, 0x28, 0x09, 0x48, 0x0d, 0x52, 0x0a, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x72,
+	0x79, 0x52, 0x61, 0x77, 0x88, 0x01, 0x01, 0x12, 0x1d, 0x0a, 0x0a, 0x72, 0x61, 0x6e, 0x67, 0x65,
+	0x5f, 0x66, 0x72, 0x6f, 0x6d, 0x18, 0x11, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x09, 0x72, 0x61, 0x6e,
+	0x67, 0x65, 0x46, 0x72, 0x6f, 0x6d, 0x12, 0x19, 0x0a, 0x08, 0x72, 0x61, 0x6e, 0x67, 0x65, 0x5f,
+	0x74, 0x6f, 0x18, 0x12, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x72, 0x61, 0x6e, 0x67, 0x65, 0x54,
+	0x6f, 0x12, 0x28, 0x0a, 0x0d, 0x69, 0x73, 0x70, 0x5f, 0x63, 0x61, 0x6e, 0x6f, 0x6e, 0x69, 0x63,
+	0x61, 0x6c, 0x18, 0x13, 0x20, 0x01, 0x28, 0x09, 0x48, 0x0e, 0x52, 0x0c, 0x69, 0x73, 0x70, 0x43,
+	0x61, 0x6e, 0x6f, 0x6e, 0x69, 0x63, 0x61, 0x6c, 0x88, 0x01, 0x01, 0x42, 0x0a, 0x0a, 0x08, 0x5f,
+	0x63, 0x6f, 0x75, 0x6e, 0x74, 0x72, 0x79, 0x42, 0x0f, 0x0a, 0x0d, 0x5f, 0x63, 0x6f, 0x75, 0x6e,
+	0x74, 0x72, 0x79, 0x5f, 0x63, 0x6f, 0x64, 0x65, 0x42, 0x07, 0x0a, 0x05, 0x5f, 0x63, 0x69, 0x74,
+	0x79, 0x42, 0x06, 0x0a, 0x04, 0x5f, 0x69, 0x73, 0x70, 0x42, 0x09, 0x0a, 0x07, 0x5f, 0x72, 0x65,
+	0x67, 0x69, 0x6f, 0x6e, 0x42, 0x09, 0x0a, 0x07, 0x5f, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x42,
+	0x0d, 0x0a, 0x0b, 0x5f, 0x75, 0x73, 0x61, 0x67, 0x65, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x42, 0x06,
+	0x0a, 0x04, 0x5f, 0x61, 0x73, 0x6e, 0x42, 0x05, 0x0a, 0x03, 0x5f, 0x61, 0x73, 0x42, 0x0c, 0x0a,
+	0x0a, 0x5f, 0x6c, 0x61, 0x73, 0x74, 0x5f, 0x73, 0x65, 0x65, 0x6e, 0x42, 0x09, 0x0a, 0x07, 0x5f,
+	0x74, 0x68, 0x72, 0x65, 0x61, 0x74, 0x42, 0x0b, 0x0a, 0x09, 0x5f, 0x70, 0x72, 0x6f, 0x76, 0x69,
+	0x64, 0x65, 0x72, 0x42, 0x0c, 0x0a, 0x0a, 0x5f, 0x72, 0x61, 0x77, 0x5f, 0x70, 0x72, 0x6f, 0x78,
+	0x79, 0x42, 0x0e, 0x0a, 0x0c, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x72, 0x79, 0x5f, 0x72, 0x61,
+	0x77, 0x42, 0x10, 0x0a, 0x0e, 0x5f, 0x69, 0x73, 0x70, 0x5f, 0x63, 0x61, 0x6e, 0x6f, 0x6e, 0x69,
+	0x63, 0x61, 0x6c, 0x2a, 0xbe, 0x01, 0x0a, 0x09, 0x50, 0x72, 0x6f, 0x78, 0x79, 0x54, 0x79, 0x70,
+	0x65, 0x12, 0x11, 0x0a, 0x0d, 0x50, 0x52, 0x4f, 0x58, 0x59, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f,
+	0x4e, 0x41, 0x10, 0x00, 0x12, 0x12, 0x0a, 0x0e, 0x50, 0x52, 0x4f, 0x58, 0x59, 0x5f, 0x54, 0x59,
+	0x50, 0x45, 0x5f, 0x4e, 0x4f, 0x54, 0x10, 0x01, 0x12, 0x12, 0x0a, 0x0e, 0x50, 0x52, 0x4f, 0x58,
+	0x59, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x56, 0x50, 0x4e, 0x10, 0x02, 0x12, 0x12, 0x0a, 0x0e,
+	0x50, 0x52, 0x4f, 0x58, 0x59, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x54, 0x4f, 0x52, 0x10, 0x03,
+	0x12, 0x12, 0x0a, 0x0e, 0x50, 0x52, 0x4f, 0x58, 0x59, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x44,
+	0x43, 0x48, 0x10, 0x04, 0x12, 0x12, 0x0a, 0x0e, 0x50, 0x52, 0x4f, 0x58, 0x59, 0x5f, 0x54, 0x59,
+	0x50, 0x45, 0x5f, 0x50, 0x55, 0x42, 0x10, 0x05, 0x12, 0x12, 0x0a, 0x0e, 0x50, 0x52, 0x4f, 0x58,
+	0x59, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x57, 0x45, 0x42, 0x10, 0x06, 0x12, 0x12, 0x0a, 0x0e,
+	0x50, 0x52, 0x4f, 0x58, 0x59, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x53, 0x45, 0x53, 0x10, 0x07,
+	0x12, 0x12, 0x0a, 0x0e, 0x50, 0x52, 0x4f, 0x58, 0x59, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x52,
+	0x45, 0x53, 0x10, 0x08, 0x42, 0x1f, 0x5a, 0x1d, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63,
+	0x6f, 0x6d, 0x2f, 0x65, 0x74, 0x66, 0x31, 0x2f, 0x69, 0x70, 0x32, 0x70, 0x72, 0x6f, 0x78, 0x79,
+	0x2f, 0x69, 0x70, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_result_proto_rawDescOnce sync.Once
+	file_result_proto_rawDescData = file_result_proto_rawDesc
+)
+
+func file_result_proto_rawDescGZIP() []byte {
+	file_result_proto_rawDescOnce.Do(func() {
+		file_result_proto_rawDescData = protoimpl.X.CompressGZIP(file_result_proto_rawDescData)
+	})
+	return file_result_proto_rawDescData
+}
+
+var file_result_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_result_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_result_proto_goTypes = []interface{}{
+	(ProxyType)(0), // 0: ip2proxy.ProxyType
+	(*Result)(nil), // 1: ip2proxy.Result
+}
+var file_result_proto_depIdxs = []int32{
+	0, // 0: ip2proxy.Result.proxy:type_name -> ip2proxy.ProxyType
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_result_proto_init() }
+func file_result_proto_init() {
+	if File_result_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_result_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Result); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_result_proto_msgTypes[0].OneofWrappers = []interface{}{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_result_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_result_proto_goTypes,
+		DependencyIndexes: file_result_proto_depIdxs,
+		EnumInfos:         file_result_proto_enumTypes,
+		MessageInfos:      file_result_proto_msgTypes,
+	}.Build()
+	File_result_proto = out.File
+	file_result_proto_rawDesc = nil
+	file_result_proto_goTypes = nil
+	file_result_proto_depIdxs = nil
+}
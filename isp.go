@@ -0,0 +1,38 @@
+package ip2proxy
+
+import "strings"
+
+// ISPSuffixes are the corporate suffixes stripped from the ISP names by CanonicalISP, lower case and without dots:
+// "S.A." is matched by "sa". It may be changed to override the default suffixes, before any db is opened with
+// WithISPCanonicalization, not being safe for concurrent changes.
+var ISPSuffixes = []string{
+	"ab", "ag", "asa", "bv", "co", "company", "corp", "corporation", "gmbh", "inc", "incorporated", "limited",
+	"llc", "ltd", "nv", "oy", "plc", "pty", "sa", "sarl", "sas", "spa", "srl",
+}
+
+// CanonicalISP returns the canonical form of an ISP name, for grouping the variants of a name: the name is lower
+// cased, its whitespaces collapsed to single spaces and its trailing corporate suffixes listed in ISPSuffixes stripped,
+// along with the commas preceding them. "Example Networks, Inc." and "EXAMPLE  networks" are both "example networks".
+// A name only made of suffixes is kept.
+func CanonicalISP(isp string) string {
+	words := strings.Fields(strings.ToLower(isp))
+	for len(words) > 1 && isISPSuffix(words[len(words)-1]) {
+		words = words[:len(words)-1]
+		words[len(words)-1] = strings.TrimRight(words[len(words)-1], ",")
+	}
+	return strings.Join(words, " ")
+}
+
+// checks if the lower cased word is a corporate suffix, or a lone comma
+func isISPSuffix(word string) bool {
+	word = strings.Replace(strings.Trim(word, ","), ".", "", -1)
+	if word == "" {
+		return true
+	}
+	for _, suffix := range ISPSuffixes {
+		if word == suffix {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,17 @@
+package ip2proxy
+
+// Logger receives the warnings about the recoverable anomalies of a db, see WithLogger. *log.Logger implements it.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// logger discarding everything, used by default
+type nopLogger struct{}
+
+// Printf discards the message
+func (nopLogger) Printf(string, ...interface{}) {}
+
+// logs a warning about the db
+func (db *DB) warnf(format string, v ...interface{}) {
+	db.opts.logger.Printf("ip2proxy: "+format, v...)
+}
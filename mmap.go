@@ -0,0 +1,66 @@
+package ip2proxy
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/juju/errors"
+)
+
+// OpenMmap opens a db file by mapping it in memory rather than reading it, so that its content stays out of the Go
+// heap and is loaded by the OS on demand. The db must be closed to release the mapping, and must not be used after.
+func OpenMmap(path string, opts ...Option) (*DB, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot open/read db file")
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot open/read db file")
+	}
+	if fi.Size() == 0 {
+		return nil, errors.Annotate(fmt.Errorf("%s is empty or not redable", path), "cannot open/read db file")
+	}
+	data, unmap, err := mmapFile(f, int(fi.Size()))
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot map db file")
+	}
+	db, err := FromBytes(data, opts...)
+	if err != nil {
+		unmap()
+		return nil, err
+	}
+	db.unmap = unmap
+	return db, nil
+}
+
+// PreloadPages makes the OS load the whole file of a db opened with OpenMmap, by reading a byte of each of its
+// memory pages, so that the first lookups don't wait for disk reads. It reads the whole file from disk when it isn't
+// in the OS page cache yet, and its pages may be evicted again under memory pressure. It does nothing for a db held in
+// memory.
+func (db *DB) PreloadPages() error {
+	if db.unmap == nil {
+		return nil
+	}
+	var sum byte
+	for i := 0; i < len(db.data); i += os.Getpagesize() {
+		sum += db.data[i]
+	}
+	// keeps the reads from being optimized out
+	runtime.KeepAlive(sum)
+	return nil
+}
+
+// Close releases the resources held by the db, like the memory mapping of a db opened with OpenMmap.
+// The db must not be used after.
+func (db *DB) Close() error {
+	if db.unmap == nil {
+		return nil
+	}
+	unmap := db.unmap
+	db.unmap = nil
+	db.data = nil
+	return unmap()
+}
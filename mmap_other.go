@@ -0,0 +1,18 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris && !windows
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris,!windows
+
+package ip2proxy
+
+import (
+	"io/ioutil"
+	"os"
+)
+
+// reads the whole file in memory, on platforms without memory mapping
+func mmapFile(f *os.File, size int) ([]byte, func() error, error) {
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return nil }, nil
+}
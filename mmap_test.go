@@ -0,0 +1,40 @@
+package ip2proxy_test
+
+import (
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/etf1/ip2proxy"
+)
+
+var _ = Describe("Mmap", func() {
+	It("should returns an error on an empty file", func() {
+		db, err := OpenMmap(filepath.Join("testdata", "empty"))
+		Expect(db).Should(BeNil())
+		Expect(err).To(MatchError("cannot open/read db file: testdata/empty is empty or not redable"))
+	})
+	It("should returns an error on a random file", func() {
+		db, err := OpenMmap(filepath.Join("testdata", "random"))
+		Expect(db).Should(BeNil())
+		Expect(err).To(MatchError("cannot read db header: invalid db format or unknown db type"))
+	})
+	It("should lookup a mapped file", func() {
+		db, err := OpenMmap(filepath.Join("testdata", "IP2PROXY-LITE-PX4.BIN"))
+		Expect(err).To(BeNil())
+		Expect(db.Version()).To(Equal("PX4-2018-02-01"))
+		res, err := db.LookupIPV4Dot("2.7.154.188")
+		Expect(err).To(BeNil())
+		Expect(res.Proxy).To(Equal(ProxyTOR))
+		Expect(db.PreloadPages()).To(Succeed())
+		Expect(db.Close()).To(Succeed())
+		Expect(db.Close()).To(Succeed())
+	})
+	It("should close a db read in memory", func() {
+		db, err := Open(filepath.Join("testdata", "IP2PROXY-LITE-PX4.BIN"))
+		Expect(err).To(BeNil())
+		Expect(db.PreloadPages()).To(Succeed())
+		Expect(db.Close()).To(Succeed())
+	})
+})
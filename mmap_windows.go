@@ -0,0 +1,26 @@
+//go:build windows
+// +build windows
+
+package ip2proxy
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// maps a file in memory, read only
+func mmapFile(f *os.File, size int) ([]byte, func() error, error) {
+	h, err := syscall.CreateFileMapping(syscall.Handle(f.Fd()), nil, syscall.PAGE_READONLY, 0, 0, nil)
+	if err != nil {
+		return nil, nil, os.NewSyscallError("CreateFileMapping", err)
+	}
+	addr, err := syscall.MapViewOfFile(h, syscall.FILE_MAP_READ, 0, 0, uintptr(size))
+	// the view keeps a reference on the mapping, which can be closed right away
+	syscall.CloseHandle(h)
+	if err != nil {
+		return nil, nil, os.NewSyscallError("MapViewOfFile", err)
+	}
+	data := unsafe.Slice((*byte)(*(*unsafe.Pointer)(unsafe.Pointer(&addr))), size)
+	return data, func() error { return os.NewSyscallError("UnmapViewOfFile", syscall.UnmapViewOfFile(addr)) }, nil
+}
@@ -0,0 +1,115 @@
+package ip2proxy
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+// MultiDB is an ordered set of dbs looked up together, such as a LITE db and a commercial one
+type MultiDB struct {
+	dbs []*DB
+}
+
+// SourceResult is the result of the lookup of an addr in a db of a MultiDB, Result being nil when the addr is not in
+// the db
+type SourceResult struct {
+	Source Source
+	Result *Result
+}
+
+// MultiDBOption configures a MultiDB
+type MultiDBOption func(*multiDBOptions)
+
+// MultiDB options
+type multiDBOptions struct {
+	maxSkew    time.Duration
+	warnSkew   time.Duration
+	skewLogger Logger
+}
+
+// WithMaxDateSkew makes NewMultiDB fail with ErrDateSkew as cause when the dates of the dbs differ by more than max,
+// see DateSkew
+func WithMaxDateSkew(max time.Duration) MultiDBOption {
+	return func(o *multiDBOptions) {
+		o.maxSkew = max
+	}
+}
+
+// WithDateSkewWarning makes NewMultiDB log a warning to l when the dates of the dbs differ by more than max, see
+// DateSkew
+func WithDateSkewWarning(max time.Duration, l Logger) MultiDBOption {
+	return func(o *multiDBOptions) {
+		o.warnSkew = max
+		o.skewLogger = l
+	}
+}
+
+// NewMultiDB creates a MultiDB of the dbs, in their order. It fails without db or when one of them is nil. Their
+// dates are checked not to differ too much when set by WithMaxDateSkew or WithDateSkewWarning, as dbs of very
+// different dates give inconsistent answers.
+func NewMultiDB(dbs []*DB, opts ...MultiDBOption) (*MultiDB, error) {
+	if len(dbs) == 0 {
+		return nil, fmt.Errorf("no db")
+	}
+	for i, db := range dbs {
+		if db == nil {
+			return nil, fmt.Errorf("db %d is nil", i)
+		}
+	}
+	var o multiDBOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	m := &MultiDB{dbs: dbs}
+	skew := m.DateSkew()
+	if o.maxSkew > 0 && skew > o.maxSkew {
+		return nil, errors.Annotatef(ErrDateSkew, "dbs dates %s differ by %s, beyond %s", m.versions(), skew, o.maxSkew)
+	}
+	if o.warnSkew > 0 && o.skewLogger != nil && skew > o.warnSkew {
+		o.skewLogger.Printf("ip2proxy: dbs dates %s differ by %s, beyond %s", m.versions(), skew, o.warnSkew)
+	}
+	return m, nil
+}
+
+// DateSkew returns the duration between the dates of the oldest and the newest dbs, 0 for less than 2 dbs
+func (m *MultiDB) DateSkew() time.Duration {
+	if len(m.dbs) == 0 {
+		return 0
+	}
+	oldest, newest := m.dbs[0].Date(), m.dbs[0].Date()
+	for _, db := range m.dbs[1:] {
+		if date := db.Date(); date.Before(oldest) {
+			oldest = date
+		} else if date.After(newest) {
+			newest = date
+		}
+	}
+	return newest.Sub(oldest)
+}
+
+// gets the versions of the dbs, for messages
+func (m *MultiDB) versions() []string {
+	versions := make([]string, len(m.dbs))
+	for i, db := range m.dbs {
+		versions[i] = db.Version()
+	}
+	return versions
+}
+
+// LookupAll lookups the ipv4 addr ip in each db, as LookupIPV4 does, returning their results separately in the dbs
+// order. It is meant to compare the answers of the dbs, e.g. to spot their disagreements. An ipv6 addr returns
+// ErrInvalidIP.
+func (m *MultiDB) LookupAll(ip net.IP) ([]SourceResult, error) {
+	results := make([]SourceResult, 0, len(m.dbs))
+	for _, db := range m.dbs {
+		res, err := db.lookupNetIPV4(ip)
+		if err != nil {
+			return nil, errors.Annotatef(err, "cannot lookup %s", db.Version())
+		}
+		results = append(results, SourceResult{Source: db.source, Result: res})
+	}
+	return results, nil
+}
@@ -0,0 +1,74 @@
+package ip2proxy_test
+
+import (
+	"bytes"
+	"log"
+	"net"
+	"path/filepath"
+	"time"
+
+	"github.com/juju/errors"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/etf1/ip2proxy"
+)
+
+var _ = Describe("MultiDB", func() {
+	var sample, lite *DB
+	var multi *MultiDB
+	BeforeEach(func() {
+		var err error
+		sample, err = Open(filepath.Join("testdata", "PX11-SAMPLE.BIN"))
+		Expect(err).To(BeNil())
+		lite, err = Open(filepath.Join("testdata", "IP2PROXY-LITE-PX4.BIN"))
+		Expect(err).To(BeNil())
+		multi, err = NewMultiDB([]*DB{sample, lite})
+		Expect(err).To(BeNil())
+	})
+	It("should return the result of each db", func() {
+		results, err := multi.LookupAll(net.ParseIP("31.31.77.107"))
+		Expect(err).To(BeNil())
+		Expect(results).To(HaveLen(2))
+		Expect(results[0].Source).To(Equal(Source{Type: PX11, Version: sample.Version()}))
+		Expect(results[1].Source).To(Equal(Source{Type: PX4, Version: lite.Version()}))
+		single, err := lite.LookupIPV4(net.ParseIP("31.31.77.107"))
+		Expect(err).To(BeNil())
+		Expect(results[1].Result).To(Equal(single))
+		single, err = sample.LookupIPV4(net.ParseIP("31.31.77.107"))
+		Expect(err).To(BeNil())
+		Expect(results[0].Result).To(Equal(single))
+	})
+	It("should return the lookup errors", func() {
+		_, err := multi.LookupAll(nil)
+		Expect(errors.Cause(err)).To(Equal(ErrInvalidIP))
+		Expect(err).To(MatchError(HavePrefix("cannot lookup " + sample.Version())))
+	})
+	It("should return an error without db or for a nil db", func() {
+		_, err := NewMultiDB(nil)
+		Expect(err).To(MatchError("no db"))
+		_, err = NewMultiDB([]*DB{})
+		Expect(err).To(MatchError("no db"))
+		_, err = NewMultiDB([]*DB{sample, nil})
+		Expect(err).To(MatchError("db 1 is nil"))
+	})
+	Context("when checking the dates skew", func() {
+		It("should return the duration between the oldest and newest dbs", func() {
+			Expect(multi.DateSkew()).To(Equal(sample.Date().Sub(lite.Date())))
+		})
+		It("should fail beyond the maximum skew", func() {
+			_, err := NewMultiDB([]*DB{sample, lite}, WithMaxDateSkew(30*24*time.Hour))
+			Expect(errors.Cause(err)).To(Equal(ErrDateSkew))
+			Expect(err).To(MatchError(HavePrefix("dbs dates [" + sample.Version() + " " + lite.Version() + "] differ by")))
+			_, err = NewMultiDB([]*DB{sample, sample}, WithMaxDateSkew(time.Hour))
+			Expect(err).To(BeNil())
+		})
+		It("should warn beyond the warning skew", func() {
+			var b bytes.Buffer
+			m, err := NewMultiDB([]*DB{lite, sample}, WithDateSkewWarning(30*24*time.Hour, log.New(&b, "", 0)))
+			Expect(err).To(BeNil())
+			Expect(m).NotTo(BeNil())
+			Expect(b.String()).To(HavePrefix("ip2proxy: dbs dates [" + lite.Version() + " " + sample.Version() + "] differ by"))
+		})
+	})
+})
@@ -0,0 +1,216 @@
+package ip2proxy
+
+import (
+	"io"
+	"time"
+)
+
+// DefaultMaxResults is the default maximum count of results returned by range lookups, see WithMaxResults
+const DefaultMaxResults = 65536
+
+// Option configures a db when opening it
+type Option func(*options)
+
+// db options
+type options struct {
+	reserved          bool
+	reservedResult    *Result
+	reservedFlag      bool
+	stringCache       bool
+	prewarm           bool
+	lazyIndex         bool
+	maxResults        int
+	interner          *Interner
+	isoCountryNames   bool
+	canonicalISP      bool
+	maxAge            time.Duration
+	columnLayout      map[Field]uint8
+	strictProxy       bool
+	logger            Logger
+	rangeCacheSize    int
+	rangeCacheTTL     time.Duration
+	expectedSHA256    string
+	notFoundResult    *Result
+	savedIndex        io.Reader
+	tracer            Tracer
+	emptyInsteadOfNil bool
+	maxLastSeenDays   int
+}
+
+// WithReserved makes lookups of private, shared, loopback, link-local, documentation and other reserved ipv4 addresses
+// (see IsReserved) return a copy of res without searching the db. If res is nil, those lookups return ErrReserved.
+func WithReserved(res *Result) Option {
+	return func(o *options) {
+		o.reserved = true
+		o.reservedResult = res
+	}
+}
+
+// WithReservedFlag makes lookups of reserved addresses (see IsReserved) set the Reserved flag of their result, the db
+// still being searched for them
+func WithReservedFlag() Option {
+	return func(o *options) {
+		o.reservedFlag = true
+	}
+}
+
+// WithNotFoundDefault makes lookups of a single addr not found in the db return a copy of res, with its IP set to the
+// addr, rather than a nil result. LookupFlat then reports the addr as found. Range lookups and iterations are
+// unaffected.
+func WithNotFoundDefault(res *Result) Option {
+	return func(o *options) {
+		o.notFoundResult = res
+	}
+}
+
+// WithStringCache makes the db cache the decoded strings, so that records sharing a string share its memory
+// and the string is only decoded once
+func WithStringCache() Option {
+	return func(o *options) {
+		o.stringCache = true
+	}
+}
+
+// WithPrewarm decodes and caches all strings of the db at opening time, using GOMAXPROCS goroutines,
+// so that the first lookups don't pay the decoding cost. It implies WithStringCache.
+func WithPrewarm() Option {
+	return func(o *options) {
+		o.stringCache = true
+		o.prewarm = true
+	}
+}
+
+// WithRangeCache makes lookups cache up to size decoded records, so that the lookups of the addrs of a cached record
+// range don't decode it again. The records of the addrs not detected as proxies are cached by range, the lookups of the
+// addrs of these gaps being answered without searching the db. Each record is cached for ttl, or until evicted by more
+// recently used ones when ttl is 0 or less. Its statistics are returned by RangeCacheStats. A size of 0 or less
+// disables the cache.
+func WithRangeCache(size int, ttl time.Duration) Option {
+	return func(o *options) {
+		o.rangeCacheSize = size
+		o.rangeCacheTTL = ttl
+	}
+}
+
+// WithLazyIndex defers the reading of each index bucket to the first lookup falling in it,
+// making opening faster when only a few lookups (or none) are made
+func WithLazyIndex() Option {
+	return func(o *options) {
+		o.lazyIndex = true
+	}
+}
+
+// WithMaxResults sets the maximum count of results returned by range lookups such as LookupAll, DefaultMaxResults
+// by default. Beyond it, they return the first max results along with ErrTooManyResults. A max of 0 or less removes
+// the limit.
+func WithMaxResults(max int) Option {
+	return func(o *options) {
+		o.maxResults = max
+	}
+}
+
+// WithInterner makes the db hold its decoded strings in the interner in, so that identical strings decoded by all dbs
+// sharing it share their memory. It is mostly useful along with WithStringCache or WithPrewarm, when several dbs are
+// kept open, e.g. to compare versions of a db.
+func WithInterner(in *Interner) Option {
+	return func(o *options) {
+		o.interner = in
+	}
+}
+
+// WithISOCountryNames replaces the country names of results by their ISO 3166-1 english short name, according to
+// their country code. The country name as stored in db is then held by the CountryRaw field. Results of unknown
+// country codes are left unchanged.
+func WithISOCountryNames() Option {
+	return func(o *options) {
+		o.isoCountryNames = true
+	}
+}
+
+// WithISPCanonicalization sets the ISPCanonical field of results to the canonical form of their ISP name, see
+// CanonicalISP, for grouping results by ISP. The ISP field is left as stored in db.
+func WithISPCanonicalization() Option {
+	return func(o *options) {
+		o.canonicalISP = true
+	}
+}
+
+// WithMaxAge makes opening a db older than maxAge fail with ErrExpired as cause, the db age being computed from its
+// date
+func WithMaxAge(maxAge time.Duration) Option {
+	return func(o *options) {
+		o.maxAge = maxAge
+	}
+}
+
+// WithMaxLastSeen makes the records of dbs with a last seen field (PX8 and above) which proxy was last seen more than
+// days ago read as ProxyNOT, their RawProxy still being the stored proxy type. It applies to lookups, range lookups
+// and iterations, and is a no-op for the other dbs and for days of 0 or less.
+func WithMaxLastSeen(days int) Option {
+	return func(o *options) {
+		o.maxLastSeenDays = days
+	}
+}
+
+// WithColumnLayout sets the columns of fields, for db files which columns are not in the standard order of their
+// type. layout maps fields to their column, from 2 as the first one holds the records first addr. The country code
+// and name share the FieldCountry column, FieldProxy is the proxy type column. The fields missing from layout keep
+// their standard column.
+func WithColumnLayout(layout map[Field]uint8) Option {
+	return func(o *options) {
+		o.columnLayout = layout
+	}
+}
+
+// WithStrictProxy makes lookups fail with ErrUnclassified as cause when the proxy type of the found record is unknown,
+// rather than returning a ProxyNA result. It has no effect on dbs without proxy type.
+func WithStrictProxy() Option {
+	return func(o *options) {
+		o.strictProxy = true
+	}
+}
+
+// WithLogger makes the db log to l warnings about the recoverable anomalies found when opening it and on lookups,
+// such as unknown proxy types, each one being logged once. Nothing is logged by default.
+func WithLogger(l Logger) Option {
+	return func(o *options) {
+		if l == nil {
+			l = nopLogger{}
+		}
+		o.logger = l
+	}
+}
+
+// WithEmptyInsteadOfNil makes the results read from the db records hold a pointer to an empty string for each of their
+// absent string fields, rather than a nil pointer, whether the db type holds the field or not. The results set by
+// WithReserved and WithNotFoundDefault are left as they are.
+func WithEmptyInsteadOfNil() Option {
+	return func(o *options) {
+		o.emptyInsteadOfNil = true
+	}
+}
+
+// WithTracer makes the lookups of a single addr run in a span started by t, holding the addr, whether it was found,
+// its proxy type and the range of its record as attributes, and the lookup error. No span is started by default.
+func WithTracer(t Tracer) Option {
+	return func(o *options) {
+		o.tracer = t
+	}
+}
+
+// WithExpectedSHA256 makes opening a db fail with ErrChecksumMismatch as cause when the SHA-256 digest of its file is
+// not sum, hex encoded. The digest is checked before parsing the file.
+func WithExpectedSHA256(sum string) Option {
+	return func(o *options) {
+		o.expectedSHA256 = sum
+	}
+}
+
+// WithSavedIndex makes opening a db load its index buckets from r, as written by SaveIndex, rather than reading them
+// from the db file. When r holds the index of another db, or cannot be read, the index is read from the db file and a
+// warning is logged.
+func WithSavedIndex(r io.Reader) Option {
+	return func(o *options) {
+		o.savedIndex = r
+	}
+}
@@ -0,0 +1,143 @@
+package ip2proxy
+
+import (
+	"strconv"
+	"sync"
+	"unsafe"
+)
+
+// ResultPool reuses results along with the storage of their strings, to look up many addrs with LookupIPV4Into
+// without allocating a result and its strings per lookup.
+//
+// The strings of a pooled result, and the pointers to them, are reused by its next lookup and once it is put back in
+// the pool: they must be copied before, e.g. with Flat, to be kept. A pool is safe for concurrent use.
+type ResultPool struct {
+	pool sync.Pool
+}
+
+// holds the strings of a pooled result
+type resultArena struct {
+	buf    []byte
+	strs   [lastField]string
+	n      int
+	source Source
+}
+
+// NewResultPool creates an empty results pool
+func NewResultPool() *ResultPool {
+	return &ResultPool{pool: sync.Pool{
+		New: func() interface{} {
+			return &Result{arena: &resultArena{buf: make([]byte, 0, 256)}}
+		},
+	}}
+}
+
+// Get returns an empty result from the pool
+func (p *ResultPool) Get() *Result {
+	return p.pool.Get().(*Result)
+}
+
+// Put resets res and puts it back in the pool, its strings being reused by the next results got from the pool
+func (p *ResultPool) Put(res *Result) {
+	if res == nil {
+		return
+	}
+	if res.arena == nil {
+		res.arena = &resultArena{}
+	}
+	res.reset()
+	p.pool.Put(res)
+}
+
+// LookupIPV4Into lookups a numeric ipv4 addr in database as LookupIPV4Num does, decoding the found record into res
+// rather than into a new result. found is false, res being left empty, when the addr is not in database. When res
+// comes from a ResultPool, its strings are held by its storage and only valid until its next lookup or its return to
+// the pool.
+func (db *DB) LookupIPV4Into(ip uint32, res *Result) (found bool, err error) {
+	res.reset()
+	return db.lookupIPV4Into(ip, res)
+}
+
+// empties the result, keeping the storage of its strings for reuse
+func (r *Result) reset() {
+	a := r.arena
+	*r = Result{arena: a}
+	if a != nil {
+		a.buf = a.buf[:0]
+		a.strs = [lastField]string{}
+		a.n = 0
+	}
+}
+
+// gets a pointer to the string s, held by the result storage when pooled
+func (r *Result) str(s string) *string {
+	a := r.arena
+	if a == nil || a.n == len(a.strs) {
+		p := new(string)
+		*p = s
+		return p
+	}
+	a.strs[a.n] = s
+	a.n++
+	return &a.strs[a.n-1]
+}
+
+// gets a pointer to a copy of the source s, held by the result storage when pooled
+func (r *Result) source(s Source) *Source {
+	if r.arena == nil {
+		p := new(Source)
+		*p = s
+		return p
+	}
+	r.arena.source = s
+	return &r.arena.source
+}
+
+// gets the dot notation of the ipv4 addr ip, held by the result storage when pooled
+func (r *Result) ipv4(ip uint32) string {
+	a := r.arena
+	if a == nil {
+		return intToIPV4(ip)
+	}
+	start := len(a.buf)
+	for shift := 24; shift >= 0; shift -= 8 {
+		if shift < 24 {
+			a.buf = append(a.buf, '.')
+		}
+		a.buf = strconv.AppendUint(a.buf, uint64(ip>>uint(shift)&0xFF), 10)
+	}
+	return bytesToString(a.buf[start:])
+}
+
+// gets the string of the bytes b, held by the result storage when pooled, or a copy of them
+func (r *Result) bytesStr(b []byte) string {
+	a := r.arena
+	if a == nil {
+		return string(b)
+	}
+	start := len(a.buf)
+	a.buf = append(a.buf, b...)
+	return bytesToString(a.buf[start:])
+}
+
+// copies the fields of src into the result, which must be empty
+func (r *Result) copyFrom(src *Result) {
+	r.IP = src.IP
+	r.Proxy = src.Proxy
+	r.RangeFrom = src.RangeFrom
+	r.RangeTo = src.RangeTo
+	r.Reserved = src.Reserved
+	if src.Source != nil {
+		r.Source = r.source(*src.Source)
+	}
+	for f := FieldCountry; f <= lastField; f++ {
+		if p := f.ptr(src); p != nil && *p != nil {
+			*f.ptr(r) = r.str(**p)
+		}
+	}
+}
+
+// gets a string sharing the memory of b, which must not be modified while the string is used
+func bytesToString(b []byte) string {
+	return *(*string)(unsafe.Pointer(&b))
+}
@@ -0,0 +1,77 @@
+package ip2proxy_test
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/etf1/ip2proxy"
+)
+
+var _ = Describe("ResultPool", func() {
+	var db *DB
+	BeforeEach(func() {
+		var err error
+		db, err = Open(filepath.Join("testdata", "PX11-SAMPLE.BIN"))
+		Expect(err).To(BeNil())
+	})
+	It("should return the same results as the lookups", func() {
+		pool := NewResultPool()
+		for _, ip := range []string{"1.0.0.1", "1.0.1.1", "2.0.0.1", "255.255.255.255", "9.9.9.9"} {
+			expected, err := db.LookupIPV4Dot(ip)
+			Expect(err).To(BeNil())
+			res := pool.Get()
+			found, err := db.LookupIPV4Into(dotToInt(ip), res)
+			Expect(err).To(BeNil())
+			Expect(found).To(BeTrue())
+			Expect(res.IP).To(Equal(ip))
+			Expect(res.Equal(expected)).To(BeTrue(), ip)
+			Expect(res.Flat()).To(Equal(expected.Flat()))
+			Expect(res.Source).To(Equal(expected.Source))
+			pool.Put(res)
+		}
+	})
+	It("should reuse the results strings", func() {
+		pool := NewResultPool()
+		res := pool.Get()
+		_, err := db.LookupIPV4Into(dotToInt("1.0.0.1"), res)
+		Expect(err).To(BeNil())
+		flat := res.Flat()
+		pool.Put(res)
+		Expect(res.ISP).To(BeNil())
+		Expect(flat.ISP).To(Equal("Sample VPN Ltd"))
+
+		lookup := func() {
+			res := pool.Get()
+			if _, err := db.LookupIPV4Into(dotToInt("1.0.0.1"), res); err != nil {
+				panic(err)
+			}
+			pool.Put(res)
+		}
+		lookup()
+		pooled := testing.AllocsPerRun(100, lookup)
+		allocs := testing.AllocsPerRun(100, func() {
+			if _, err := db.LookupIPV4Dot("1.0.0.1"); err != nil {
+				panic(err)
+			}
+		})
+		Expect(pooled).To(BeNumerically("<", allocs/4))
+	})
+	It("should leave the result empty when not found", func() {
+		data, err := ioutil.ReadFile(filepath.Join("testdata", "PX11-SAMPLE.BIN"))
+		Expect(err).To(BeNil())
+		// the first record starting at 0.1.0.0
+		binary.LittleEndian.PutUint32(data[64+65536*8:], 65536)
+		db, err := FromBytes(data)
+		Expect(err).To(BeNil())
+		res := NewResultPool().Get()
+		found, err := db.LookupIPV4Into(dotToInt("0.0.0.1"), res)
+		Expect(err).To(BeNil())
+		Expect(found).To(BeFalse())
+		Expect(res.IP).To(BeEmpty())
+	})
+})
@@ -0,0 +1,436 @@
+package ip2proxy
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net"
+	"sort"
+
+	"github.com/juju/errors"
+)
+
+// Range is a range of addrs held by a record, From and To included
+type Range struct {
+	From   uint32
+	To     uint32
+	Result *Result
+}
+
+// Contains checks if the range holds the addr ip
+func (r Range) Contains(ip uint32) bool {
+	return r.From <= ip && ip <= r.To
+}
+
+// CIDRs returns the smallest list of CIDRs covering the range, in ascending addrs order, nil for an empty range
+func (r Range) CIDRs() []net.IPNet {
+	if r.From > r.To {
+		return nil
+	}
+	blocks := rangeCIDRs(r.From, r.To)
+	cidrs := make([]net.IPNet, len(blocks))
+	for i, block := range blocks {
+		cidrs[i] = *block
+	}
+	return cidrs
+}
+
+// String returns the range in the dot notation as from-to
+func (r Range) String() string {
+	return intToIPV4(r.From) + "-" + intToIPV4(r.To)
+}
+
+// LookupAll lookups the records holding the ipv4 addrs from from to to included. It returns a result per distinct
+// record, with its IP set to the first addr of its range and RangeFrom and RangeTo set to the addrs range it holds,
+// clipped to from and to. Reserved addrs are returned as stored in db.
+// Beyond the maximum count of results set by WithMaxResults, it returns the first ones along with ErrTooManyResults.
+func (db *DB) LookupAll(from, to uint32) ([]*Result, error) {
+	if from > to {
+		return nil, fmt.Errorf("invalid range %s-%s", intToIPV4(from), intToIPV4(to))
+	}
+	if err := db.checkRecords(); err != nil {
+		return nil, err
+	}
+	// the search starts at the first record when from is below it
+	if to < db.bounds[0] {
+		return nil, nil
+	}
+	off, err := db.findPosForIPV4(max32(from, db.bounds[0]))
+	if err != nil {
+		return nil, err
+	}
+	if off == 0 {
+		return nil, nil
+	}
+	var results []*Result
+	err = db.iterate(db.rowIndex(off), func(res *Result) (bool, error) {
+		if res.RangeFrom > to {
+			return false, nil
+		}
+		if res.RangeTo < from {
+			// the lookup of from, starting the next record, met the record ending before it
+			return true, nil
+		}
+		if db.opts.maxResults > 0 && len(results) == db.opts.maxResults {
+			return false, ErrTooManyResults
+		}
+		res.RangeFrom = max32(res.RangeFrom, from)
+		res.RangeTo = min32(res.RangeTo, to)
+		res.IP = intToIPV4(res.RangeFrom)
+		results = append(results, res)
+		return true, nil
+	})
+	return results, err
+}
+
+// LookupRange lookups the records holding the addrs from from to to included, as LookupAll does. Both bounds must be
+// ipv4 addrs, else it returns an error which cause is ErrInvalidIP.
+func (db *DB) LookupRange(from, to net.IP) ([]*Result, error) {
+	from4, to4 := from.To4(), to.To4()
+	if from4 == nil && to4 == nil {
+		return nil, errors.Annotatef(ErrInvalidIP, "range %s-%s is not an ipv4 range", from, to)
+	}
+	if from4 == nil || to4 == nil {
+		return nil, errors.Annotatef(ErrInvalidIP, "range %s-%s mixes ipv4 and ipv6 addrs", from, to)
+	}
+	return db.LookupAll(binary.BigEndian.Uint32(from4), binary.BigEndian.Uint32(to4))
+}
+
+// LookupCIDRs lookups the records holding the addrs of each ipv4 CIDR of cidrs, as LookupAll does, returning them
+// indexed by CIDR. Overlapping or adjacent CIDRs are looked up in a single scan, the maximum count of results set by
+// WithMaxResults then applying to the whole scan. Duplicate CIDRs are looked up once. Beyond the maximum count of
+// results, it returns the results of the first ones along with ErrTooManyResults.
+func (db *DB) LookupCIDRs(cidrs []string) (map[string][]*Result, error) {
+	type prefix struct {
+		cidr     string
+		from, to uint32
+	}
+	prefixes := make([]prefix, 0, len(cidrs))
+	seen := make(map[string]bool, len(cidrs))
+	for _, cidr := range cidrs {
+		from, to, err := parseCIDRV4(cidr)
+		if err != nil {
+			return nil, err
+		}
+		if seen[cidr] {
+			continue
+		}
+		seen[cidr] = true
+		prefixes = append(prefixes, prefix{cidr: cidr, from: from, to: to})
+	}
+	sort.Slice(prefixes, func(i, j int) bool { return prefixes[i].from < prefixes[j].from })
+
+	results := make(map[string][]*Result, len(prefixes))
+	for i := 0; i < len(prefixes); {
+		// groups the prefixes scanned at once
+		j, to := i+1, prefixes[i].to
+		for ; j < len(prefixes) && to != math.MaxUint32 && prefixes[j].from <= to+1; j++ {
+			to = max32(to, prefixes[j].to)
+		}
+		scan, err := db.LookupAll(prefixes[i].from, to)
+		if err != nil && err != ErrTooManyResults {
+			return nil, err
+		}
+		for _, p := range prefixes[i:j] {
+			for _, res := range scan {
+				if res.RangeTo < p.from || res.RangeFrom > p.to {
+					continue
+				}
+				if j-i > 1 {
+					res = res.clone()
+				}
+				res.RangeFrom = max32(res.RangeFrom, p.from)
+				res.RangeTo = min32(res.RangeTo, p.to)
+				res.IP = intToIPV4(res.RangeFrom)
+				results[p.cidr] = append(results[p.cidr], res)
+			}
+		}
+		if err != nil {
+			return results, err
+		}
+		i = j
+	}
+	return results, nil
+}
+
+// Iterate calls fn for each record of the db, in ascending addrs order, with its IP set to the first addr of its
+// range and RangeFrom and RangeTo set to the addrs range it holds. The iteration stops at the first error returned by
+// fn, which Iterate returns.
+func (db *DB) Iterate(fn func(res *Result) error) error {
+	return db.iterate(0, func(res *Result) (bool, error) {
+		return true, fn(res)
+	})
+}
+
+// IterateReverse calls fn for each record of the db as Iterate does, in descending addrs order. The iteration stops at
+// the first error returned by fn, which IterateReverse returns.
+func (db *DB) IterateReverse(fn func(res *Result) error) error {
+	for i := db.header.Count - 1; i > 0; i-- {
+		res, err := db.readRow(i - 1)
+		if err != nil {
+			return err
+		}
+		if res == nil {
+			continue
+		}
+		if err := fn(res); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IterateByType calls fn for each record of the db of proxy type t, in ascending addrs order, with the addrs range
+// it holds. The iteration stops at the first error returned by fn, which IterateByType returns.
+func (db *DB) IterateByType(t ProxyType, fn func(from, to uint32, res *Result) error) error {
+	return db.Iterate(func(res *Result) error {
+		if res.Proxy != t {
+			return nil
+		}
+		return fn(res.RangeFrom, res.RangeTo, res)
+	})
+}
+
+// calls fn for each record from the row i, until it returns false or an error.
+// Rows holding no addr, starting where the next row starts, are skipped.
+func (db *DB) iterate(i uint32, fn func(res *Result) (bool, error)) error {
+	for ; i+1 < db.header.Count; i++ {
+		res, err := db.readRow(i)
+		if err != nil {
+			return err
+		}
+		if res == nil {
+			continue
+		}
+		if ok, err := fn(res); !ok || err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RecordAt reads the record of the row i, without searching it, i going from 0 to Count()-2 as the last row holds no
+// record but the end of the previous one. from and to are the addrs range it holds, from its ipFrom up to the ipFrom
+// of the next row excluded, and the result IP is set to from. Lookups may also return it for the ipFrom of the next
+// row, shared by both records. The range is empty, from being above to, when the next row starts at the same addr,
+// Iterate skipping such rows.
+func (db *DB) RecordAt(i uint32) (from, to uint32, r *Result, err error) {
+	if err := db.checkRecords(); err != nil {
+		return 0, 0, nil, err
+	}
+	if uint64(i)+1 >= uint64(db.header.Count) {
+		return 0, 0, nil, fmt.Errorf("record %d beyond the %d records", i, max32(db.header.Count, 1)-1)
+	}
+	if from, to, err = db.rowRange(i); err != nil {
+		return 0, 0, nil, err
+	}
+	if r, err = db.readIPV4Record(recordOffset(db.ipv4(), i)); err != nil {
+		return 0, 0, nil, errors.Annotatef(err, "cannot read record %d", i)
+	}
+	r.IP = intToIPV4(from)
+	r.RangeFrom = from
+	r.RangeTo = to
+	return from, to, r, nil
+}
+
+// reads the record of the row i, with its IP set to the first addr of its range and RangeFrom and RangeTo set to the
+// addrs range it holds, nil when the row holds no addr
+func (db *DB) readRow(i uint32) (*Result, error) {
+	from, to, err := db.rowRange(i)
+	if err != nil || from > to {
+		return nil, err
+	}
+	res, err := db.readIPV4Record(recordOffset(db.ipv4(), i))
+	if err != nil {
+		return nil, errors.Annotatef(err, "cannot read record %d", i)
+	}
+	res.IP = intToIPV4(from)
+	res.RangeFrom = from
+	res.RangeTo = to
+	return res, nil
+}
+
+// EffectiveCount returns the number of records holding addrs, the ones Iterate walks: it leaves out the last row,
+// holding no record, and the rows holding no addr, starting where the next row starts.
+// It reads the ranges of all the rows.
+func (db *DB) EffectiveCount() (uint32, error) {
+	count := uint32(0)
+	for i := uint32(0); i+1 < db.header.Count; i++ {
+		from, to, err := db.rowRange(i)
+		if err != nil {
+			return 0, err
+		}
+		if from <= to {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// IPv4Bounds returns the lowest and highest ipv4 addrs covered by the db records, 0.0.0.0 and 255.255.255.255 for a
+// db covering the whole address space. The highest one is the addr before the ipFrom of the last row, which holds no
+// record but the end of the previous one, or 255.255.255.255 when the last row starts at it.
+func (db *DB) IPv4Bounds() (from, to uint32, err error) {
+	if err := db.checkRecords(); err != nil {
+		return 0, 0, err
+	}
+	return db.bounds[0], db.bounds[1], nil
+}
+
+// CoverageGaps returns the ranges of ipv4 addrs covered by no record, in ascending order, their Result being nil. A db
+// covering the whole address space has none. As each record ends where the next one starts, gaps are usually only
+// found before the first record or after the last one. It reads all the records.
+func (db *DB) CoverageGaps() ([]Range, error) {
+	var gaps []Range
+	next := uint64(0)
+	err := db.Iterate(func(res *Result) error {
+		if uint64(res.RangeFrom) > next {
+			gaps = append(gaps, Range{From: uint32(next), To: res.RangeFrom - 1})
+		}
+		if uint64(res.RangeTo)+1 > next {
+			next = uint64(res.RangeTo) + 1
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if next <= math.MaxUint32 {
+		gaps = append(gaps, Range{From: uint32(next), To: math.MaxUint32})
+	}
+	return gaps, nil
+}
+
+// LookupFloor lookups the record starting at the highest addr not above the ipv4 addr ip, whether or not it holds ip,
+// attributing the addrs beyond the last record to it. The result IP is set to ip and RangeFrom and RangeTo to the
+// addrs range the record holds, ip holding it when within. It returns nil for an addr below the first record, and
+// ErrInvalidIP for an ipv6 addr.
+func (db *DB) LookupFloor(ip net.IP) (*Result, error) {
+	ipnum, err := netIPV4ToInt(ip)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.checkRecords(); err != nil {
+		return nil, err
+	}
+	if db.header.Count < 2 || ipnum < db.bounds[0] {
+		return nil, nil
+	}
+	// searches the first row starting above ip, the last row holding no record
+	low, high := uint32(0), db.header.Count-1
+	for low < high {
+		mid := low + (high-low)/2
+		from, err := db.readUint32(db.rowOffset(mid))
+		if err != nil {
+			return nil, errors.Annotatef(err, "cannot read record %d", mid)
+		}
+		if from > ipnum {
+			high = mid
+		} else {
+			low = mid + 1
+		}
+	}
+	// the rows holding no addr are left out
+	for i := low; i > 0; i-- {
+		res, err := db.readRow(i - 1)
+		if err != nil {
+			return nil, err
+		}
+		if res != nil {
+			res.IP = intToIPV4(ipnum)
+			return res, nil
+		}
+	}
+	return nil, nil
+}
+
+// reads the lowest and highest ipv4 addrs covered by the db records
+func (db *DB) readIPv4Bounds() (from, to uint32, err error) {
+	if from, err = db.readUint32(db.rowOffset(0)); err != nil {
+		return 0, 0, errors.Annotate(err, "cannot read first record")
+	}
+	if to, err = db.readUint32(db.rowOffset(db.header.Count - 1)); err != nil {
+		return 0, 0, errors.Annotate(err, "cannot read last record")
+	}
+	// the last row starts after the records, unless at the highest addr
+	if to != math.MaxUint32 && to > 0 {
+		to--
+	}
+	return from, to, nil
+}
+
+// gets the index of the row at byte offset off
+func (db *DB) rowIndex(off uint32) uint32 {
+	return (off - (db.header.BaseAddr - 1)) / uint32(db.header.IPv4ColumnSize)
+}
+
+// gets the addrs range held by the row i, from its ipFrom up to the ipFrom of the next row excluded, which lookups
+// may also resolve to the row i. The range is empty, from being above to, when the next row starts at the same addr.
+func (db *DB) rowRange(i uint32) (from, to uint32, err error) {
+	if err := db.checkRecords(); err != nil {
+		return 0, 0, err
+	}
+	if from, err = db.readUint32(db.rowOffset(i)); err != nil {
+		return 0, 0, errors.Annotatef(err, "cannot read record %d", i)
+	}
+	if to, err = db.readUint32(db.rowOffset(i + 1)); err != nil {
+		return 0, 0, errors.Annotatef(err, "cannot read record %d", i+1)
+	}
+	switch {
+	case to == math.MaxUint32 && i+2 == db.header.Count:
+		// the last record holds the highest addr
+	case to <= from:
+		return 1, 0, nil
+	default:
+		to--
+	}
+	return from, to, nil
+}
+
+// splits the addrs range from-to into the smallest list of CIDRs covering it
+func rangeCIDRs(from, to uint32) []*net.IPNet {
+	var cidrs []*net.IPNet
+	for start := uint64(from); start <= uint64(to); {
+		// the largest block aligned on start and not going beyond to
+		bits := uint(0)
+		for bits < 32 && start&(1<<(bits+1)-1) == 0 && start+1<<(bits+1)-1 <= uint64(to) {
+			bits++
+		}
+		cidrs = append(cidrs, &net.IPNet{IP: intToNetIPV4(uint32(start)), Mask: net.CIDRMask(32-int(bits), 32)})
+		start += 1 << bits
+	}
+	return cidrs
+}
+
+// parses an ipv4 CIDR, returning its first and last addrs
+func parseCIDRV4(cidr string) (uint32, uint32, error) {
+	_, ipnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid ipv4 CIDR %q", cidr)
+	}
+	ones, bits := ipnet.Mask.Size()
+	if bits != 8*net.IPv4len {
+		return 0, 0, fmt.Errorf("invalid ipv4 CIDR %q", cidr)
+	}
+	from, err := ipV4ToInt(ipnet.IP)
+	if err != nil {
+		return 0, 0, err
+	}
+	return from, from | uint32(math.MaxUint32>>uint(ones)), nil
+}
+
+// gets the greater of a and b
+func max32(a, b uint32) uint32 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// gets the lesser of a and b
+func min32(a, b uint32) uint32 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,391 @@
+package ip2proxy_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"math"
+	"net"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/etf1/ip2proxy"
+)
+
+var _ = Describe("Range", func() {
+	Context("when operating on a range", func() {
+		It("should check the addrs it holds", func() {
+			r := Range{From: dotToInt("1.0.0.0"), To: dotToInt("1.0.0.255")}
+			Expect(r.Contains(dotToInt("1.0.0.0"))).To(BeTrue())
+			Expect(r.Contains(dotToInt("1.0.0.255"))).To(BeTrue())
+			Expect(r.Contains(dotToInt("1.0.1.0"))).To(BeFalse())
+			Expect(r.Contains(dotToInt("0.255.255.255"))).To(BeFalse())
+		})
+		It("should return its CIDRs", func() {
+			r := Range{From: dotToInt("1.0.0.1"), To: dotToInt("1.0.0.8")}
+			var cidrs []string
+			for _, cidr := range r.CIDRs() {
+				cidrs = append(cidrs, cidr.String())
+			}
+			Expect(cidrs).To(Equal([]string{"1.0.0.1/32", "1.0.0.2/31", "1.0.0.4/30", "1.0.0.8/32"}))
+			Expect(Range{From: 0, To: math.MaxUint32}.CIDRs()[0].String()).To(Equal("0.0.0.0/0"))
+			Expect(Range{From: 2, To: 1}.CIDRs()).To(BeNil())
+		})
+		It("should format it", func() {
+			Expect(Range{From: dotToInt("1.0.0.0"), To: dotToInt("1.0.0.255")}.String()).To(Equal("1.0.0.0-1.0.0.255"))
+		})
+	})
+	Context("with the sample db", func() {
+		var db *DB
+		BeforeEach(func() {
+			var err error
+			db, err = Open(filepath.Join("testdata", "PX11-SAMPLE.BIN"))
+			Expect(err).To(BeNil())
+		})
+		It("should return every record of the db", func() {
+			results, err := db.LookupAll(0, math.MaxUint32)
+			Expect(err).To(BeNil())
+			type rng struct {
+				from, to string
+				proxy    ProxyType
+			}
+			var ranges []rng
+			for _, res := range results {
+				ranges = append(ranges, rng{res.IP, intToDot(res.RangeTo), res.Proxy})
+			}
+			Expect(ranges).To(Equal([]rng{
+				{"0.0.0.0", "0.255.255.255", ProxyNOT},
+				{"1.0.0.0", "1.0.0.255", ProxyVPN},
+				{"1.0.1.0", "1.0.1.0", ProxyTOR},
+				{"1.0.1.1", "1.255.255.255", ProxyNOT},
+				{"2.0.0.0", "2.0.0.255", ProxyDCH},
+				{"2.0.1.0", "2.255.255.255", ProxyNOT},
+				{"3.0.0.0", "3.0.0.255", ProxyPUB},
+				{"3.0.1.0", "3.255.255.255", ProxyNOT},
+				{"4.0.0.0", "4.0.0.0", ProxyWEB},
+				{"4.0.0.1", "255.255.254.255", ProxyNOT},
+				{"255.255.255.0", "255.255.255.255", ProxyVPN},
+			}))
+		})
+		It("should return the addrs bounds of the db", func() {
+			from, to, err := db.IPv4Bounds()
+			Expect(err).To(BeNil())
+			Expect(from).To(Equal(uint32(0)))
+			Expect(to).To(Equal(uint32(math.MaxUint32)))
+		})
+		It("should iterate over every record of the db", func() {
+			var ips []string
+			Expect(db.Iterate(func(res *Result) error {
+				ips = append(ips, res.IP)
+				return nil
+			})).To(Succeed())
+			Expect(ips).To(HaveLen(11))
+			Expect(ips[2]).To(Equal("1.0.1.0"))
+			count := 0
+			err := db.Iterate(func(res *Result) error {
+				count++
+				if res.Proxy == ProxyDCH {
+					return errors.New("stop")
+				}
+				return nil
+			})
+			Expect(err).To(MatchError("stop"))
+			Expect(count).To(Equal(5))
+		})
+		It("should iterate over every record of the db in reverse order", func() {
+			var forward, reverse []*Result
+			Expect(db.Iterate(func(res *Result) error {
+				forward = append(forward, res)
+				return nil
+			})).To(Succeed())
+			Expect(db.IterateReverse(func(res *Result) error {
+				reverse = append(reverse, res)
+				return nil
+			})).To(Succeed())
+			Expect(reverse).To(HaveLen(len(forward)))
+			for i, res := range reverse {
+				Expect(res).To(Equal(forward[len(forward)-1-i]))
+			}
+			count := 0
+			err := db.IterateReverse(func(res *Result) error {
+				count++
+				if res.Proxy == ProxyDCH {
+					return errors.New("stop")
+				}
+				return nil
+			})
+			Expect(err).To(MatchError("stop"))
+			Expect(count).To(Equal(7))
+		})
+		It("should return the highest range", func() {
+			res, err := db.LookupIPV4Dot("255.255.255.255")
+			Expect(err).To(BeNil())
+			Expect(res.Proxy).To(Equal(ProxyVPN))
+			results, err := db.LookupAll(math.MaxUint32, math.MaxUint32)
+			Expect(err).To(BeNil())
+			Expect(results).To(HaveLen(1))
+			Expect(results[0].RangeFrom).To(Equal(uint32(math.MaxUint32)))
+			Expect(results[0].Proxy).To(Equal(ProxyVPN))
+		})
+		It("should have no coverage gap", func() {
+			gaps, err := db.CoverageGaps()
+			Expect(err).To(BeNil())
+			Expect(gaps).To(BeEmpty())
+		})
+		It("should return the record below an addr", func() {
+			Expect(db.Iterate(func(res *Result) error {
+				for _, ip := range []uint32{res.RangeFrom, res.RangeTo} {
+					floor, err := db.LookupFloor(net.ParseIP(intToDot(ip)))
+					Expect(err).To(BeNil())
+					Expect(floor.IP).To(Equal(intToDot(ip)))
+					Expect(floor.RangeFrom).To(Equal(res.RangeFrom))
+					Expect(floor.Proxy).To(Equal(res.Proxy))
+				}
+				return nil
+			})).To(Succeed())
+			_, err := db.LookupFloor(nil)
+			Expect(err).To(HaveOccurred())
+		})
+		It("should read the record of a row", func() {
+			var i uint32
+			Expect(db.Iterate(func(res *Result) error {
+				from, to, r, err := db.RecordAt(i)
+				Expect(err).To(BeNil())
+				Expect(from).To(Equal(res.RangeFrom))
+				Expect(to).To(Equal(res.RangeTo))
+				Expect(r).To(Equal(res))
+				i++
+				return nil
+			})).To(Succeed())
+			_, _, _, err := db.RecordAt(db.Count() - 1)
+			Expect(err).To(MatchError("record 11 beyond the 11 records"))
+		})
+		It("should count the records holding addrs", func() {
+			count, err := db.EffectiveCount()
+			Expect(err).To(BeNil())
+			Expect(count).To(Equal(uint32(11)))
+			Expect(db.Count()).To(Equal(count + 1))
+		})
+		It("should iterate over the records of a proxy type", func() {
+			var ranges [][2]uint32
+			Expect(db.IterateByType(ProxyVPN, func(from, to uint32, res *Result) error {
+				Expect(res.Proxy).To(Equal(ProxyVPN))
+				ranges = append(ranges, [2]uint32{from, to})
+				return nil
+			})).To(Succeed())
+			Expect(ranges).To(Equal([][2]uint32{
+				{dotToInt("1.0.0.0"), dotToInt("1.0.0.255")},
+				{dotToInt("255.255.255.0"), dotToInt("255.255.255.255")},
+			}))
+			err := db.IterateByType(ProxyTOR, func(from, to uint32, res *Result) error {
+				return errors.New("stop")
+			})
+			Expect(err).To(MatchError("stop"))
+		})
+		It("should clip the records to the range", func() {
+			results, err := db.LookupAll(16777344, 33554442)
+			Expect(err).To(BeNil())
+			Expect(results).To(HaveLen(4))
+			Expect(results[0].IP).To(Equal("1.0.0.128"))
+			Expect(results[0].RangeFrom).To(Equal(uint32(16777344)))
+			Expect(*results[0].Provider).To(Equal("SampleVPN"))
+			Expect(results[3].Proxy).To(Equal(ProxyDCH))
+			Expect(results[3].RangeTo).To(Equal(uint32(33554442)))
+		})
+		It("should return a single record for a range inside a record", func() {
+			results, err := db.LookupAll(50331658, 50331658)
+			Expect(err).To(BeNil())
+			Expect(results).To(HaveLen(1))
+			Expect(results[0].Proxy).To(Equal(ProxyPUB))
+			Expect(results[0].RangeFrom).To(Equal(results[0].RangeTo))
+		})
+		It("should return an error beyond the maximum count of results", func() {
+			db, err := Open(filepath.Join("testdata", "PX11-SAMPLE.BIN"), WithMaxResults(3))
+			Expect(err).To(BeNil())
+			results, err := db.LookupAll(0, math.MaxUint32)
+			Expect(err).To(Equal(ErrTooManyResults))
+			Expect(results).To(HaveLen(3))
+			results, err = db.LookupAll(0, 16777472)
+			Expect(err).To(BeNil())
+			Expect(results).To(HaveLen(3))
+		})
+		It("should not limit the count of results when unlimited", func() {
+			db, err := Open(filepath.Join("testdata", "IP2PROXY-LITE-PX4.BIN"), WithMaxResults(0))
+			Expect(err).To(BeNil())
+			results, err := db.LookupAll(0, math.MaxUint32)
+			Expect(err).To(BeNil())
+			Expect(len(results)).To(BeNumerically(">", DefaultMaxResults))
+		})
+		It("should return the records of a net.IP range", func() {
+			results, err := db.LookupRange(net.ParseIP("1.0.0.128"), net.ParseIP("2.0.0.0"))
+			Expect(err).To(BeNil())
+			var ips []string
+			for _, res := range results {
+				ips = append(ips, res.IP)
+			}
+			Expect(ips).To(Equal([]string{"1.0.0.128", "1.0.1.0", "1.0.1.1", "2.0.0.0"}))
+			_, err = db.LookupRange(net.ParseIP("2.0.0.0"), net.ParseIP("1.0.0.0"))
+			Expect(err).To(MatchError("invalid range 2.0.0.0-1.0.0.0"))
+		})
+		It("should return an error for a net.IP range which is not ipv4", func() {
+			_, err := db.LookupRange(net.ParseIP("1.0.0.0"), net.ParseIP("::1"))
+			Expect(err).To(MatchError("range 1.0.0.0-::1 mixes ipv4 and ipv6 addrs: invalid IP"))
+			_, err = db.LookupRange(net.ParseIP("::1"), nil)
+			Expect(err).To(MatchError("range ::1-<nil> is not an ipv4 range: invalid IP"))
+		})
+		It("should return the records of each CIDR", func() {
+			results, err := db.LookupCIDRs([]string{"3.0.0.0/24", "1.0.0.128/25", "1.0.1.0/30", "2.0.0.0/8", "4.0.0.0/32"})
+			Expect(err).To(BeNil())
+			Expect(results).To(HaveLen(5))
+			Expect(results["1.0.0.128/25"]).To(HaveLen(1))
+			Expect(results["1.0.0.128/25"][0].IP).To(Equal("1.0.0.128"))
+			Expect(results["1.0.0.128/25"][0].Proxy).To(Equal(ProxyVPN))
+			Expect(results["1.0.1.0/30"]).To(HaveLen(2))
+			Expect(results["1.0.1.0/30"][0].Proxy).To(Equal(ProxyTOR))
+			Expect(results["1.0.1.0/30"][1].RangeTo).To(Equal(dotToInt("1.0.1.3")))
+			Expect(results["2.0.0.0/8"]).To(HaveLen(2))
+			Expect(results["2.0.0.0/8"][1].RangeTo).To(Equal(dotToInt("2.255.255.255")))
+			Expect(results["3.0.0.0/24"]).To(HaveLen(1))
+			Expect(results["3.0.0.0/24"][0].Proxy).To(Equal(ProxyPUB))
+			Expect(results["4.0.0.0/32"]).To(HaveLen(1))
+			Expect(results["4.0.0.0/32"][0].Proxy).To(Equal(ProxyWEB))
+		})
+		It("should look up duplicate CIDRs once", func() {
+			results, err := db.LookupCIDRs([]string{"1.0.1.0/30", "3.0.0.0/24", "1.0.1.0/30"})
+			Expect(err).To(BeNil())
+			Expect(results).To(HaveLen(2))
+			Expect(results["1.0.1.0/30"]).To(HaveLen(2))
+			Expect(results["3.0.0.0/24"]).To(HaveLen(1))
+		})
+		It("should return the first results beyond the maximum count of results", func() {
+			db, err := Open(filepath.Join("testdata", "PX11-SAMPLE.BIN"), WithMaxResults(3))
+			Expect(err).To(BeNil())
+			results, err := db.LookupCIDRs([]string{"0.0.0.0/0"})
+			Expect(err).To(Equal(ErrTooManyResults))
+			Expect(results["0.0.0.0/0"]).To(HaveLen(3))
+			scan, _ := db.LookupAll(0, math.MaxUint32)
+			Expect(results["0.0.0.0/0"]).To(Equal(scan))
+		})
+		It("should return an error for an invalid CIDR", func() {
+			_, err := db.LookupCIDRs([]string{"1.0.0.0/8", "lol"})
+			Expect(err).To(MatchError(`invalid ipv4 CIDR "lol"`))
+			_, err = db.LookupCIDRs([]string{"2001:db8::/32"})
+			Expect(err).To(MatchError(`invalid ipv4 CIDR "2001:db8::/32"`))
+		})
+		It("should return an error for an invalid range", func() {
+			_, err := db.LookupAll(2, 1)
+			Expect(err).To(MatchError("invalid range 0.0.0.2-0.0.0.1"))
+		})
+	})
+	It("should skip a leading zero range row", func() {
+		b, err := NewBuilder(PX1, time.Date(2021, time.Month(6), 1, 0, 0, 0, 0, time.UTC))
+		Expect(err).To(BeNil())
+		code, name := "FR", "France"
+		Expect(b.Add(dotToInt("1.0.0.0"), dotToInt("1.0.0.255"), &Result{CountryCode: &code, Country: &name})).To(Succeed())
+		data, err := b.Bytes()
+		Expect(err).To(BeNil())
+		// moves the first record start to the start of the leading row
+		binary.LittleEndian.PutUint32(data[64+65536*8+8:], 0)
+		db, err := FromBytes(data)
+		Expect(err).To(BeNil())
+		Expect(db.Count()).To(Equal(uint32(4)))
+		count, err := db.EffectiveCount()
+		Expect(err).To(BeNil())
+		Expect(count).To(Equal(uint32(2)))
+		var results []*Result
+		Expect(db.Iterate(func(res *Result) error {
+			results = append(results, res)
+			return nil
+		})).To(Succeed())
+		Expect(results).To(HaveLen(2))
+		Expect(results[0].RangeFrom).To(Equal(uint32(0)))
+		Expect(results[0].RangeTo).To(Equal(dotToInt("1.0.0.255")))
+		Expect(*results[0].Country).To(Equal("France"))
+		res, err := db.LookupIPV4Dot("0.0.0.0")
+		Expect(err).To(BeNil())
+		Expect(*res.Country).To(Equal("France"))
+	})
+	It("should not read beyond the last row", func() {
+		data, err := ioutil.ReadFile(filepath.Join("testdata", "PX11-SAMPLE.BIN"))
+		Expect(err).To(BeNil())
+		// the last row at 255.255.255.254 ending the file, the last index bucket spanning it
+		binary.LittleEndian.PutUint32(data[64+65535*8+4:], 11)
+		binary.LittleEndian.PutUint32(data[64+65536*8+11*52:], dotToInt("255.255.255.254"))
+		data = data[:64+65536*8+12*52]
+		db, err := FromBytes(data)
+		Expect(err).To(BeNil())
+		_, to, err := db.IPv4Bounds()
+		Expect(err).To(BeNil())
+		Expect(to).To(Equal(dotToInt("255.255.255.253")))
+		results, err := db.LookupAll(math.MaxUint32, math.MaxUint32)
+		Expect(err).To(BeNil())
+		Expect(results).To(BeEmpty())
+	})
+	It("should return the last record as the floor of the addrs beyond it", func() {
+		data, err := ioutil.ReadFile(filepath.Join("testdata", "PX11-SAMPLE.BIN"))
+		Expect(err).To(BeNil())
+		// the last row at 255.255.255.128
+		binary.LittleEndian.PutUint32(data[64+65536*8+11*52:], dotToInt("255.255.255.128"))
+		db, err := FromBytes(data)
+		Expect(err).To(BeNil())
+		res, err := db.LookupIPV4Dot("255.255.255.200")
+		Expect(err).To(BeNil())
+		Expect(res).To(BeNil())
+		res, err = db.LookupFloor(net.ParseIP("255.255.255.200"))
+		Expect(err).To(BeNil())
+		Expect(res.Proxy).To(Equal(ProxyVPN))
+		Expect(res.IP).To(Equal("255.255.255.200"))
+		Expect(res.RangeFrom).To(Equal(dotToInt("255.255.255.0")))
+		Expect(res.RangeTo).To(BeNumerically("<", dotToInt("255.255.255.200")))
+	})
+	It("should start at the record starting at the first addr", func() {
+		db, err := Open(filepath.Join("testdata", "IP2PROXY-LITE-PX4.BIN"))
+		Expect(err).To(BeNil())
+		// the lookup of 2.6.120.66 returns the PUB record ending before it
+		res, err := db.LookupIPV4Dot("2.6.120.66")
+		Expect(err).To(BeNil())
+		Expect(res.Proxy).To(Equal(ProxyPUB))
+		results, err := db.LookupAll(dotToInt("2.6.120.66"), dotToInt("2.6.120.66"))
+		Expect(err).To(BeNil())
+		Expect(results).To(HaveLen(1))
+		Expect(results[0].Proxy).To(Equal(ProxyNOT))
+	})
+	It("should match the lookups of each addr", func() {
+		db, err := Open(filepath.Join("testdata", "IP2PROXY-LITE-PX4.BIN"))
+		Expect(err).To(BeNil())
+		from := dotToInt("2.6.112.0")
+		to := dotToInt("2.6.127.255")
+		results, err := db.LookupAll(from, to)
+		Expect(err).To(BeNil())
+		Expect(len(results)).To(BeNumerically(">", 1))
+		Expect(results[0].RangeFrom).To(Equal(from))
+		Expect(results[len(results)-1].RangeTo).To(Equal(to))
+		for i, res := range results {
+			if i > 0 {
+				Expect(res.RangeFrom).To(Equal(results[i-1].RangeTo + 1))
+			}
+			// the first addr is shared with the previous record, the one the lookup search meets first holding it
+			for ip := res.RangeFrom + 1; ip <= res.RangeTo; ip++ {
+				single, err := db.LookupIPV4Num(ip)
+				Expect(err).To(BeNil())
+				Expect(single.Proxy).To(Equal(res.Proxy), intToDot(ip))
+				Expect(single.ISP).To(Equal(res.ISP), intToDot(ip))
+			}
+		}
+	})
+})
+
+// converts a dot notation ipv4 addr to its numeric value
+func dotToInt(ip string) uint32 {
+	return binary.BigEndian.Uint32(net.ParseIP(ip).To4())
+}
+
+// converts a numeric ipv4 addr to its dot notation
+func intToDot(ip uint32) string {
+	b := make(net.IP, net.IPv4len)
+	binary.BigEndian.PutUint32(b, ip)
+	return b.String()
+}
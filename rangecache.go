@@ -0,0 +1,252 @@
+package ip2proxy
+
+import (
+	"container/list"
+	"sort"
+	"sync"
+	"time"
+)
+
+// RangeCacheStats holds the statistics of the range cache of a db, see WithRangeCache
+type RangeCacheStats struct {
+	// Hits is the count of lookups served from the cache
+	Hits uint64
+	// Misses is the count of lookups which record was decoded, the cache not holding it or holding it expired
+	Misses uint64
+	// Evictions is the count of records evicted from the full cache
+	Evictions uint64
+	// Len is the count of records held by the cache
+	Len int
+}
+
+// concurrency safe LRU cache of decoded records, indexed by the first addr of their row.
+// The records of the addrs not detected as proxies are cached as gaps instead, indexed by the addrs range strictly
+// inside their row, so that the lookups of these addrs are answered without searching the db.
+type rangeCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	entries map[uint32]*list.Element
+	gaps    []*list.Element // sorted by ascending from, their ranges not overlapping
+	lru     *list.List
+	stats   RangeCacheStats
+}
+
+// a cached record, or gap holding the addrs from to to of the row at byte offset pos
+type rangeCacheEntry struct {
+	from    uint32
+	to      uint32
+	pos     uint32
+	gap     bool
+	res     *Result
+	expires time.Time
+}
+
+// creates a range cache holding up to size records, each one for ttl if positive
+func newRangeCache(size int, ttl time.Duration) *rangeCache {
+	return &rangeCache{size: size, ttl: ttl, entries: map[uint32]*list.Element{}, lru: list.New()}
+}
+
+// checks if an entry expired
+func (c *rangeCache) expired(entry *rangeCacheEntry) bool {
+	return c.ttl > 0 && time.Now().After(entry.expires)
+}
+
+// gets the index of the first gap starting at or above from
+func (c *rangeCache) gapIndex(from uint32) int {
+	return sort.Search(len(c.gaps), func(i int) bool {
+		return c.gaps[i].Value.(*rangeCacheEntry).from >= from
+	})
+}
+
+// removes an entry
+func (c *rangeCache) remove(elem *list.Element) {
+	c.lru.Remove(elem)
+	entry := elem.Value.(*rangeCacheEntry)
+	if !entry.gap {
+		delete(c.entries, entry.from)
+		return
+	}
+	i := c.gapIndex(entry.from)
+	c.gaps = append(c.gaps[:i], c.gaps[i+1:]...)
+}
+
+// evicts the least recently used entry when the cache is full
+func (c *rangeCache) evict() {
+	if c.lru.Len() >= c.size {
+		c.remove(c.lru.Back())
+		c.stats.Evictions++
+	}
+}
+
+// gets the record of the row starting at from, counting a miss when absent or expired
+func (c *rangeCache) get(from uint32) (*Result, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[from]
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+	entry := elem.Value.(*rangeCacheEntry)
+	if c.expired(entry) {
+		c.remove(elem)
+		c.stats.Misses++
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	c.stats.Hits++
+	return entry.res, true
+}
+
+// caches the record of the row starting at from, evicting the least recently used one when full
+func (c *rangeCache) set(from uint32, res *Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := &rangeCacheEntry{from: from, res: res, expires: time.Now().Add(c.ttl)}
+	if elem, ok := c.entries[from]; ok {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.evict()
+	c.entries[from] = c.lru.PushFront(entry)
+}
+
+// gets the record of the gap holding ip and the byte offset of its row. An absent gap counts no miss, the lookup
+// then searching the record
+func (c *rangeCache) getGap(ip uint32) (*Result, uint32, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := sort.Search(len(c.gaps), func(i int) bool { return c.gaps[i].Value.(*rangeCacheEntry).from > ip })
+	if i == 0 {
+		return nil, 0, false
+	}
+	elem := c.gaps[i-1]
+	entry := elem.Value.(*rangeCacheEntry)
+	if ip > entry.to {
+		return nil, 0, false
+	}
+	if c.expired(entry) {
+		c.remove(elem)
+		return nil, 0, false
+	}
+	c.lru.MoveToFront(elem)
+	c.stats.Hits++
+	return entry.res, entry.pos, true
+}
+
+// caches the record of the gap holding the addrs from to to of the row at byte offset pos, evicting the least
+// recently used entry when full
+func (c *rangeCache) setGap(from, to, pos uint32, res *Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := &rangeCacheEntry{from: from, to: to, pos: pos, gap: true, res: res, expires: time.Now().Add(c.ttl)}
+	if i := c.gapIndex(from); i < len(c.gaps) && c.gaps[i].Value.(*rangeCacheEntry).from == from {
+		c.gaps[i].Value = entry
+		c.lru.MoveToFront(c.gaps[i])
+		return
+	}
+	c.evict()
+	i := c.gapIndex(from)
+	c.gaps = append(c.gaps, nil)
+	copy(c.gaps[i+1:], c.gaps[i:])
+	c.gaps[i] = c.lru.PushFront(entry)
+}
+
+// gets the cache statistics
+func (c *rangeCache) getStats() RangeCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Len = c.lru.Len()
+	return stats
+}
+
+// reads the record at byte offset pos, from the range cache when enabled
+func (db *DB) readCachedIPV4Record(pos uint32, res *Result) error {
+	if db.ranges == nil {
+		return db.readIPV4RecordInto(pos, res)
+	}
+	from, err := db.readUint32(pos)
+	if err != nil {
+		return err
+	}
+	if cached, ok := db.ranges.get(from); ok {
+		res.copyFrom(cached)
+		return nil
+	}
+	// the cached record is decoded apart, as the strings of a pooled result are reused
+	cached, err := db.readIPV4Record(pos)
+	if err != nil {
+		return err
+	}
+	to, gap := uint32(0), false
+	if cached.Proxy == ProxyNOT {
+		if to, gap, err = db.gapEnd(pos, from); err != nil {
+			return err
+		}
+	}
+	if gap {
+		db.ranges.setGap(from+1, to, pos, cached)
+	} else {
+		db.ranges.set(from, cached)
+	}
+	res.copyFrom(cached)
+	return nil
+}
+
+// gets the last addr of the gap strictly inside the row at byte offset pos starting at from, the addrs at its bounds
+// being held by the neighbour records too. It returns false when the gap holds no addr, or when the rows around it
+// are out of order, the search then possibly answering other records for its addrs.
+func (db *DB) gapEnd(pos, from uint32) (uint32, bool, error) {
+	size := uint32(db.header.IPv4ColumnSize)
+	next, err := db.readUint32(pos + size)
+	if err != nil {
+		return 0, false, err
+	}
+	if next <= from+1 {
+		return 0, false, nil
+	}
+	if pos > db.rowOffset(0) {
+		prev, err := db.readUint32(pos - size)
+		if err != nil {
+			return 0, false, err
+		}
+		if prev > from {
+			return 0, false, nil
+		}
+	}
+	if pos+2*size <= db.rowOffset(db.header.Count-1) {
+		after, err := db.readUint32(pos + 2*size)
+		if err != nil {
+			return 0, false, err
+		}
+		if after < next {
+			return 0, false, nil
+		}
+	}
+	return next - 1, true, nil
+}
+
+// reads the record of the gap holding ip from the range cache into res, returning the byte offset of its row, 0
+// when no cached gap holds ip
+func (db *DB) readCachedGap(ip uint32, res *Result) uint32 {
+	if db.ranges == nil {
+		return 0
+	}
+	cached, pos, ok := db.ranges.getGap(ip)
+	if !ok {
+		return 0
+	}
+	res.copyFrom(cached)
+	return pos
+}
+
+// RangeCacheStats returns the statistics of the range cache, all zero when the db has none
+func (db *DB) RangeCacheStats() RangeCacheStats {
+	if db.ranges == nil {
+		return RangeCacheStats{}
+	}
+	return db.ranges.getStats()
+}
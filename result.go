@@ -0,0 +1,369 @@
+package ip2proxy
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// version of the Result binary encoding
+const resultBinaryVersion = 1
+
+// ResultSchemaVersion is the version of the Result json encoding, held by its schema_version field. It is increased
+// when the encoding changes, e.g. when fields are added to results.
+const ResultSchemaVersion = 1
+
+// FlatResult holds the lookup results as plain values, absent fields being empty
+type FlatResult struct {
+	IP           string    `json:"ip"`
+	Country      string    `json:"country"`
+	CountryCode  string    `json:"country_code"`
+	City         string    `json:"city"`
+	ISP          string    `json:"isp"`
+	Region       string    `json:"region"`
+	Proxy        ProxyType `json:"proxy"`
+	Domain       string    `json:"domain"`
+	UsageType    string    `json:"usage_type"`
+	ASN          string    `json:"asn"`
+	AS           string    `json:"as"`
+	LastSeen     string    `json:"last_seen"`
+	Threat       string    `json:"threat"`
+	Provider     string    `json:"provider"`
+	RawProxy     string    `json:"raw_proxy"`
+	CountryRaw   string    `json:"country_raw"`
+	ISPCanonical string    `json:"isp_canonical"`
+}
+
+// Flat returns the result as plain values
+func (r *Result) Flat() FlatResult {
+	return FlatResult{
+		IP:           r.IP,
+		Country:      strOrEmpty(r.Country),
+		CountryCode:  strOrEmpty(r.CountryCode),
+		City:         strOrEmpty(r.City),
+		ISP:          strOrEmpty(r.ISP),
+		Region:       strOrEmpty(r.Region),
+		Proxy:        r.Proxy,
+		Domain:       strOrEmpty(r.Domain),
+		UsageType:    strOrEmpty(r.UsageType),
+		ASN:          strOrEmpty(r.ASN),
+		AS:           strOrEmpty(r.AS),
+		LastSeen:     strOrEmpty(r.LastSeen),
+		Threat:       strOrEmpty(r.Threat),
+		Provider:     strOrEmpty(r.Provider),
+		RawProxy:     strOrEmpty(r.RawProxy),
+		CountryRaw:   strOrEmpty(r.CountryRaw),
+		ISPCanonical: strOrEmpty(r.ISPCanonical),
+	}
+}
+
+// Map returns the result fields in a map indexed by their json name. The ip and proxy fields are always present,
+// the other ones only when they are set. Values are strings. The source and reserved flag are left out.
+func (r *Result) Map() map[string]interface{} {
+	m := map[string]interface{}{
+		"ip":    r.IP,
+		"proxy": r.Proxy.String(),
+	}
+	fields := map[string]*string{
+		"country":       r.Country,
+		"country_code":  r.CountryCode,
+		"city":          r.City,
+		"isp":           r.ISP,
+		"region":        r.Region,
+		"domain":        r.Domain,
+		"usage_type":    r.UsageType,
+		"asn":           r.ASN,
+		"as":            r.AS,
+		"last_seen":     r.LastSeen,
+		"threat":        r.Threat,
+		"provider":      r.Provider,
+		"raw_proxy":     r.RawProxy,
+		"country_raw":   r.CountryRaw,
+		"isp_canonical": r.ISPCanonical,
+	}
+	for k, v := range fields {
+		if v != nil {
+			m[k] = *v
+		}
+	}
+	return m
+}
+
+// FieldValue is the value of a result field, see OrderedFields
+type FieldValue struct {
+	Name  string
+	Value string
+}
+
+// OrderedFields returns the values of the fields held by the db type t, in the order of their columns (see
+// DbType.Fields), named by their json name. The value of an absent field is empty, the proxy type value is its name,
+// or its raw value when unknown.
+func (r *Result) OrderedFields(t DbType) []FieldValue {
+	fields := t.Fields()
+	values := make([]FieldValue, 0, len(fields))
+	for _, f := range fields {
+		v, ok := f.value(r)
+		if f == FieldProxy && !ok {
+			v = strOrEmpty(r.RawProxy)
+		}
+		values = append(values, FieldValue{Name: f.String(), Value: v})
+	}
+	return values
+}
+
+// Pretty returns the result as a human readable block of aligned labelled lines, leaving out the absent fields
+func (r *Result) Pretty() string {
+	type line struct{ label, value string }
+	lines := []line{{"IP", r.IP}}
+	switch {
+	case r.Country != nil && r.CountryCode != nil:
+		lines = append(lines, line{"Country", fmt.Sprintf("%s (%s)", *r.Country, *r.CountryCode)})
+	case r.Country != nil:
+		lines = append(lines, line{"Country", *r.Country})
+	case r.CountryCode != nil:
+		lines = append(lines, line{"Country", *r.CountryCode})
+	}
+	for _, f := range []struct {
+		label string
+		value *string
+	}{
+		{"Region", r.Region},
+		{"City", r.City},
+		{"ISP", r.ISP},
+	} {
+		if f.value != nil {
+			lines = append(lines, line{f.label, *f.value})
+		}
+	}
+	proxy := fmt.Sprintf("%s (%s)", r.Proxy, r.Proxy.Description())
+	if r.Proxy == ProxyNA && r.RawProxy != nil {
+		proxy = *r.RawProxy
+	}
+	lines = append(lines, line{"Proxy", proxy})
+	for _, f := range []struct {
+		label string
+		value *string
+	}{
+		{"Domain", r.Domain},
+		{"Usage type", r.UsageType},
+		{"ASN", r.ASN},
+		{"AS", r.AS},
+		{"Last seen", r.LastSeen},
+		{"Threat", r.Threat},
+		{"Provider", r.Provider},
+	} {
+		if f.value != nil {
+			lines = append(lines, line{f.label, *f.value})
+		}
+	}
+	width := 0
+	for _, l := range lines {
+		if len(l.label) > width {
+			width = len(l.label)
+		}
+	}
+	var b strings.Builder
+	for i, l := range lines {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(&b, "%-*s %s", width+1, l.label+":", l.value)
+	}
+	return b.String()
+}
+
+// Network returns the network identity of the result: its autonomous system name, or its ISP when the AS name is
+// absent, and its autonomous system number, 0 when absent or invalid. ok is false when there is neither a name nor
+// a number.
+func (r *Result) Network() (name string, asn uint32, ok bool) {
+	if r.ASN != nil {
+		if n, err := strconv.ParseUint(*r.ASN, 10, 32); err == nil {
+			asn = uint32(n)
+		}
+	}
+	switch {
+	case r.AS != nil:
+		name = *r.AS
+	case r.ISP != nil:
+		name = *r.ISP
+	}
+	return name, asn, name != "" || asn != 0
+}
+
+// LastSeenDays returns the number of days since the proxy was last seen, ok being false when the last seen field is
+// absent or invalid
+func (r *Result) LastSeenDays() (days int, ok bool) {
+	if r.LastSeen == nil {
+		return 0, false
+	}
+	days, err := strconv.Atoi(*r.LastSeen)
+	if err != nil || days < 0 {
+		return 0, false
+	}
+	return days, true
+}
+
+// IsSearchEngine checks if the result is a search engine robot, a crawler which may be allowed where proxies are not
+func (r *Result) IsSearchEngine() bool {
+	return r.Proxy == ProxySES
+}
+
+// Equal checks if r and other hold the same fields values, regardless of their IP, reserved flag, range and source
+func (r *Result) Equal(other *Result) bool {
+	if r == nil || other == nil {
+		return r == other
+	}
+	if r.Proxy != other.Proxy {
+		return false
+	}
+	for f := FieldCountry; f <= lastField; f++ {
+		v, ok := f.value(r)
+		otherV, otherOk := f.value(other)
+		if ok != otherOk || v != otherV {
+			return false
+		}
+	}
+	return true
+}
+
+// Diff returns the values of r and other for each field which values differ, absent values being nil. The proxy type
+// is compared by name, a nil result holding no field.
+func (r *Result) Diff(other *Result) map[Field][2]*string {
+	diff := map[Field][2]*string{}
+	for f := FieldCountry; f <= lastField; f++ {
+		v, otherV := f.valuePtr(r), f.valuePtr(other)
+		if (v == nil) != (otherV == nil) || v != nil && *v != *otherV {
+			diff[f] = [2]*string{v, otherV}
+		}
+	}
+	return diff
+}
+
+// MarshalJSON encodes the result as a json object, its first field being the schema_version of the encoding. It
+// implements json.Marshaler.
+func (r *Result) MarshalJSON() ([]byte, error) {
+	// the result fields without the Result methods
+	type result Result
+	return json.Marshal(struct {
+		SchemaVersion int `json:"schema_version"`
+		*result
+	}{ResultSchemaVersion, (*result)(r)})
+}
+
+// MarshalBinary encodes the result in a compact binary form: a version byte, the proxy type, the uvarint encoded
+// RangeFrom and RangeTo, the length prefixed IP, then each present field as its Field byte followed by its length
+// prefixed value. The source and reserved flag are not encoded. It implements encoding.BinaryMarshaler.
+func (r *Result) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, 0, 64)
+	buf = append(buf, resultBinaryVersion, uint8(r.Proxy))
+	buf = appendUvarint(buf, uint64(r.RangeFrom))
+	buf = appendUvarint(buf, uint64(r.RangeTo))
+	buf = appendBinaryStr(buf, r.IP)
+	for f := FieldCountry; f <= lastField; f++ {
+		if p := f.ptr(r); p != nil && *p != nil {
+			buf = appendBinaryStr(append(buf, uint8(f)), **p)
+		}
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary decodes a result encoded by MarshalBinary. It implements encoding.BinaryUnmarshaler.
+func (r *Result) UnmarshalBinary(data []byte) error {
+	if len(data) < 2 || data[0] != resultBinaryVersion {
+		return fmt.Errorf("invalid result binary encoding")
+	}
+	res := Result{Proxy: ProxyType(data[1])}
+	data = data[2:]
+	for _, n := range []*uint32{&res.RangeFrom, &res.RangeTo} {
+		v, size := binary.Uvarint(data)
+		if size <= 0 || v > math.MaxUint32 {
+			return fmt.Errorf("invalid result binary encoding")
+		}
+		*n = uint32(v)
+		data = data[size:]
+	}
+	var err error
+	if res.IP, data, err = readBinaryStr(data); err != nil {
+		return err
+	}
+	for len(data) > 0 {
+		p := Field(data[0]).ptr(&res)
+		if p == nil {
+			return fmt.Errorf("invalid result binary encoding: unknown field %d", data[0])
+		}
+		var s string
+		if s, data, err = readBinaryStr(data[1:]); err != nil {
+			return err
+		}
+		*p = &s
+	}
+	*r = res
+	return nil
+}
+
+// appends an uvarint
+func appendUvarint(buf []byte, v uint64) []byte {
+	var b [binary.MaxVarintLen64]byte
+	return append(buf, b[:binary.PutUvarint(b[:], v)]...)
+}
+
+// appends a length prefixed string
+func appendBinaryStr(buf []byte, s string) []byte {
+	return append(appendUvarint(buf, uint64(len(s))), s...)
+}
+
+// reads a length prefixed string, returning the remaining data
+func readBinaryStr(data []byte) (string, []byte, error) {
+	n, size := binary.Uvarint(data)
+	if size <= 0 || n > uint64(len(data)-size) {
+		return "", nil, fmt.Errorf("invalid result binary encoding")
+	}
+	end := size + int(n)
+	return string(data[size:end]), data[end:], nil
+}
+
+// returns a deep copy of the result
+func (r *Result) clone() *Result {
+	c := *r
+	c.Country = cloneStr(r.Country)
+	c.CountryCode = cloneStr(r.CountryCode)
+	c.City = cloneStr(r.City)
+	c.ISP = cloneStr(r.ISP)
+	c.Region = cloneStr(r.Region)
+	c.Domain = cloneStr(r.Domain)
+	c.UsageType = cloneStr(r.UsageType)
+	c.ASN = cloneStr(r.ASN)
+	c.AS = cloneStr(r.AS)
+	c.LastSeen = cloneStr(r.LastSeen)
+	c.Threat = cloneStr(r.Threat)
+	c.Provider = cloneStr(r.Provider)
+	c.RawProxy = cloneStr(r.RawProxy)
+	c.CountryRaw = cloneStr(r.CountryRaw)
+	c.ISPCanonical = cloneStr(r.ISPCanonical)
+	if r.Source != nil {
+		source := *r.Source
+		c.Source = &source
+	}
+	c.arena = nil
+	return &c
+}
+
+// gets a string value, or "" when absent
+func strOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// copies a string pointer
+func cloneStr(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	c := *s
+	return &c
+}
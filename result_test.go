@@ -0,0 +1,255 @@
+package ip2proxy_test
+
+import (
+	"encoding/json"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/etf1/ip2proxy"
+)
+
+var _ = Describe("Result", func() {
+	ptrStr := func(str string) *string { return &str }
+
+	Context("when pretty printing", func() {
+		It("should align the present fields", func() {
+			r := &Result{
+				IP:          "8.8.8.8",
+				Country:     ptrStr("United States"),
+				CountryCode: ptrStr("US"),
+				ISP:         ptrStr("Google LLC"),
+				Proxy:       ProxyDCH,
+				UsageType:   ptrStr("DCH"),
+			}
+			Expect(r.Pretty()).To(Equal(strings.Join([]string{
+				"IP:         8.8.8.8",
+				"Country:    United States (US)",
+				"ISP:        Google LLC",
+				"Proxy:      DCH (Hosting provider, data center or content delivery network)",
+				"Usage type: DCH",
+			}, "\n")))
+		})
+		It("should print the raw proxy type when unknown", func() {
+			r := &Result{IP: "1.2.3.4", CountryCode: ptrStr("FR"), RawProxy: ptrStr("XYZ")}
+			Expect(r.Pretty()).To(Equal("IP:      1.2.3.4\nCountry: FR\nProxy:   XYZ"))
+		})
+	})
+	Context("when comparing", func() {
+		It("should compare the fields values", func() {
+			r := &Result{IP: "1.2.3.4", Country: ptrStr("France"), ISP: ptrStr("Orange"), Proxy: ProxyPUB}
+			Expect(r.Equal(&Result{IP: "4.3.2.1", Country: ptrStr("France"), ISP: ptrStr("Orange"), Proxy: ProxyPUB, RangeTo: 5})).To(BeTrue())
+			Expect(r.Equal(&Result{Country: ptrStr("France"), ISP: ptrStr("Orange"), Proxy: ProxyVPN})).To(BeFalse())
+			Expect(r.Equal(&Result{Country: ptrStr("France"), ISP: ptrStr("SFR"), Proxy: ProxyPUB})).To(BeFalse())
+			Expect(r.Equal(&Result{Country: ptrStr("France"), Proxy: ProxyPUB})).To(BeFalse())
+			Expect(r.Equal(&Result{Country: ptrStr("France"), ISP: ptrStr("Orange"), City: ptrStr(""), Proxy: ProxyPUB})).To(BeFalse())
+		})
+		It("should return the differing fields values", func() {
+			r := &Result{Country: ptrStr("France"), ISP: ptrStr("Orange"), Proxy: ProxyPUB}
+			Expect(r.Diff(&Result{Country: ptrStr("France"), City: ptrStr("Paris"), ISP: ptrStr("SFR"), Proxy: ProxyNOT})).To(Equal(
+				map[Field][2]*string{
+					FieldCity:  {nil, ptrStr("Paris")},
+					FieldISP:   {ptrStr("Orange"), ptrStr("SFR")},
+					FieldProxy: {ptrStr("PUB"), ptrStr("NOT")},
+				},
+			))
+			Expect(r.Diff(nil)).To(HaveLen(3))
+			Expect(r.Diff(r)).To(BeEmpty())
+		})
+		It("should handle nil results", func() {
+			var r *Result
+			Expect(r.Equal(nil)).To(BeTrue())
+			Expect(r.Equal(&Result{})).To(BeFalse())
+			Expect((&Result{}).Equal(nil)).To(BeFalse())
+		})
+	})
+	Context("when encoding to binary", func() {
+		It("should decode the encoded result", func() {
+			for _, r := range []*Result{
+				{IP: "1.2.3.4", Proxy: ProxyNOT},
+				{
+					IP:          "2.6.120.66",
+					Country:     ptrStr("France"),
+					CountryCode: ptrStr("FR"),
+					ISP:         ptrStr(""),
+					Proxy:       ProxyPUB,
+					Provider:    ptrStr("Provider"),
+					RangeFrom:   33978432,
+					RangeTo:     4294967295,
+				},
+			} {
+				data, err := r.MarshalBinary()
+				Expect(err).To(BeNil())
+				decoded := &Result{}
+				Expect(decoded.UnmarshalBinary(data)).To(Succeed())
+				Expect(decoded).To(Equal(r))
+			}
+		})
+		It("should be smaller than json", func() {
+			r := &Result{IP: "2.6.120.66", Country: ptrStr("France"), CountryCode: ptrStr("FR"), Proxy: ProxyPUB}
+			data, err := r.MarshalBinary()
+			Expect(err).To(BeNil())
+			Expect(data).To(HaveLen(27))
+			js, err := json.Marshal(r)
+			Expect(err).To(BeNil())
+			Expect(len(data)).To(BeNumerically("<", len(js)/2))
+		})
+		It("should return an error for invalid data", func() {
+			r := &Result{}
+			Expect(r.UnmarshalBinary(nil)).To(MatchError("invalid result binary encoding"))
+			Expect(r.UnmarshalBinary([]byte{1, 1, 0, 0, 5, '1'})).To(MatchError("invalid result binary encoding"))
+			Expect(r.UnmarshalBinary([]byte{1, 1, 0, 0, 0, 42, 0})).To(MatchError("invalid result binary encoding: unknown field 42"))
+		})
+	})
+	Context("when converting to a map", func() {
+		It("should only hold the ip and proxy fields for an empty result", func() {
+			r := &Result{IP: "1.2.3.4", Proxy: ProxyNOT}
+			Expect(r.Map()).To(Equal(map[string]interface{}{
+				"ip":    "1.2.3.4",
+				"proxy": "NOT",
+			}))
+		})
+		It("should hold the present fields with their json names", func() {
+			r := &Result{
+				IP:          "2.6.120.66",
+				Country:     ptrStr("France"),
+				CountryCode: ptrStr("FR"),
+				ISP:         ptrStr("France Telecom S.A."),
+				Proxy:       ProxyPUB,
+			}
+			m := r.Map()
+			Expect(m).To(Equal(map[string]interface{}{
+				"ip":           "2.6.120.66",
+				"country":      "France",
+				"country_code": "FR",
+				"isp":          "France Telecom S.A.",
+				"proxy":        "PUB",
+			}))
+			b, err := json.Marshal(r)
+			Expect(err).To(BeNil())
+			var decoded map[string]interface{}
+			Expect(json.Unmarshal(b, &decoded)).To(Succeed())
+			Expect(decoded).To(HaveKeyWithValue("schema_version", float64(ResultSchemaVersion)))
+			delete(decoded, "schema_version")
+			Expect(decoded).To(Equal(m))
+		})
+	})
+	Context("when encoding in json", func() {
+		It("should lead with the schema version", func() {
+			r := &Result{IP: "1.2.3.4", ISP: ptrStr("Orange"), Proxy: ProxyPUB, RangeFrom: 1, RangeTo: 2}
+			b, err := json.Marshal(r)
+			Expect(err).To(BeNil())
+			Expect(string(b)).To(Equal(`{"schema_version":1,"ip":"1.2.3.4","isp":"Orange","proxy":"PUB","range_from":1,"range_to":2}`))
+			var decoded Result
+			Expect(json.Unmarshal(b, &decoded)).To(Succeed())
+			Expect(&decoded).To(Equal(r))
+		})
+	})
+	Context("when getting the ordered fields", func() {
+		It("should return the fields of the db type in their columns order", func() {
+			r := &Result{IP: "1.2.3.4", Country: ptrStr("France"), CountryCode: ptrStr("FR"), ISP: ptrStr("Orange"), Proxy: ProxyPUB}
+			Expect(r.OrderedFields(PX4)).To(Equal([]FieldValue{
+				{Name: "proxy", Value: "PUB"},
+				{Name: "country_code", Value: "FR"},
+				{Name: "country", Value: "France"},
+				{Name: "region", Value: ""},
+				{Name: "city", Value: ""},
+				{Name: "isp", Value: "Orange"},
+			}))
+			Expect(r.OrderedFields(PX1)).To(Equal([]FieldValue{
+				{Name: "country_code", Value: "FR"},
+				{Name: "country", Value: "France"},
+			}))
+		})
+		It("should return the raw proxy type when unknown", func() {
+			r := &Result{RawProxy: ptrStr("XYZ")}
+			Expect(r.OrderedFields(PX2)[0]).To(Equal(FieldValue{Name: "proxy", Value: "XYZ"}))
+		})
+	})
+	Context("when getting the network identity", func() {
+		It("should combine the as name and number", func() {
+			r := &Result{ISP: ptrStr("Google LLC"), AS: ptrStr("Google"), ASN: ptrStr("15169")}
+			name, asn, ok := r.Network()
+			Expect(ok).To(BeTrue())
+			Expect(name).To(Equal("Google"))
+			Expect(asn).To(Equal(uint32(15169)))
+		})
+		It("should fallback to the isp when the as name is absent", func() {
+			r := &Result{ISP: ptrStr("France Telecom S.A.")}
+			name, asn, ok := r.Network()
+			Expect(ok).To(BeTrue())
+			Expect(name).To(Equal("France Telecom S.A."))
+			Expect(asn).To(BeZero())
+		})
+		It("should not be ok without any identity", func() {
+			_, _, ok := (&Result{ASN: ptrStr("invalid")}).Network()
+			Expect(ok).To(BeFalse())
+		})
+	})
+	Context("when encoding proxy types", func() {
+		It("should encode and decode their names", func() {
+			for t := ProxyNA; t <= ProxyRES; t++ {
+				text, err := t.MarshalText()
+				Expect(err).To(BeNil())
+				var decoded ProxyType
+				Expect(decoded.UnmarshalText(text)).To(Succeed())
+				Expect(decoded).To(Equal(t))
+			}
+		})
+		It("should decode their descriptions", func() {
+			for t := ProxyNA; t <= ProxyRES; t++ {
+				decoded, ok := ProxyTypeFromDescription(t.Description())
+				Expect(ok).To(BeTrue())
+				Expect(decoded).To(Equal(t))
+			}
+			decoded, ok := ProxyTypeFromDescription("tor EXIT node")
+			Expect(ok).To(BeTrue())
+			Expect(decoded).To(Equal(ProxyTOR))
+			decoded, ok = ProxyTypeFromDescription("Bulletproof hosting")
+			Expect(ok).To(BeFalse())
+			Expect(decoded).To(Equal(ProxyNA))
+		})
+		It("should score their risk", func() {
+			Expect(ProxyNOT.RiskScore()).To(BeZero())
+			Expect(ProxyNA.RiskScore()).To(BeZero())
+			Expect(ProxyDCH.RiskScore()).To(Equal(50))
+			Expect(ProxyTOR.RiskScore()).To(Equal(100))
+			for t := ProxyVPN; t <= ProxyRES; t++ {
+				Expect(t.RiskScore()).To(BeNumerically(">", 0))
+			}
+			RiskScoreOverrides[ProxyDCH] = 20
+			defer delete(RiskScoreOverrides, ProxyDCH)
+			Expect(ProxyDCH.RiskScore()).To(Equal(20))
+			Expect(ProxyVPN.RiskScore()).To(Equal(90))
+		})
+	})
+	Context("when decoding usage types", func() {
+		It("should parse them", func() {
+			t, ok := (&Result{UsageType: ptrStr("ISP/MOB")}).Usage()
+			Expect(ok).To(BeTrue())
+			Expect(t).To(Equal(UsageISP | UsageMOB))
+			Expect(t.String()).To(Equal("ISP/MOB"))
+			Expect(t.IsMobile()).To(BeTrue())
+			Expect(t.IsHosting()).To(BeFalse())
+			Expect(t.Has(UsageISP)).To(BeTrue())
+			Expect(t.Has(UsageISP | UsageDCH)).To(BeFalse())
+			t, ok = ParseUsageType("MIL")
+			Expect(ok).To(BeTrue())
+			Expect(t.IsGovernment()).To(BeTrue())
+			t, ok = ParseUsageType("CDN")
+			Expect(ok).To(BeTrue())
+			Expect(t.IsHosting()).To(BeTrue())
+			Expect(t.IsEducation()).To(BeFalse())
+		})
+		It("should not be ok for unknown usage types", func() {
+			t, ok := ParseUsageType("DCH/XYZ")
+			Expect(ok).To(BeFalse())
+			Expect(t).To(Equal(UsageDCH))
+			_, ok = (&Result{UsageType: ptrStr("-")}).Usage()
+			Expect(ok).To(BeFalse())
+			_, ok = (&Result{}).Usage()
+			Expect(ok).To(BeFalse())
+		})
+	})
+})
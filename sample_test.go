@@ -0,0 +1,276 @@
+package ip2proxy_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/etf1/ip2proxy"
+)
+
+var _ = Describe("Sample", func() {
+	ptrStr := func(str string) *string { return &str }
+	source := &Source{Type: PX11, Version: "PX11-2021-06-01"}
+	var db *DB
+	BeforeEach(func() {
+		var err error
+		db, err = Open(filepath.Join("testdata", "PX11-SAMPLE.BIN"))
+		Expect(err).To(BeNil())
+	})
+	It("should be built from the sample csv", func() {
+		f, err := os.Open(filepath.Join("testdata", "PX11-SAMPLE.csv"))
+		Expect(err).To(BeNil())
+		defer f.Close()
+		var buf bytes.Buffer
+		Expect(BuildFromCSV(f, &buf, PX11, time.Date(2021, time.Month(6), 1, 0, 0, 0, 0, time.UTC))).To(Succeed())
+		data, err := ioutil.ReadFile(filepath.Join("testdata", "PX11-SAMPLE.BIN"))
+		Expect(err).To(BeNil())
+		Expect(buf.Bytes()).To(Equal(data))
+	})
+	It("should return the valid header infos", func() {
+		Expect(db.Type()).To(Equal(PX11))
+		Expect(db.Version()).To(Equal("PX11-2021-06-01"))
+		Expect(db.Count()).To(Equal(uint32(12)))
+		Expect(db.Columns()).To(Equal(13))
+		Expect(db.RowSize()).To(Equal(52))
+		Expect(db.HasIPv6()).To(BeFalse())
+		Expect(db.Verify()).To(Succeed())
+	})
+	It("should return the records proxy types", func() {
+		list := map[string]ProxyType{
+			"0.0.0.0":         ProxyNOT,
+			"0.255.255.255":   ProxyNOT,
+			"1.0.0.0":         ProxyVPN,
+			"1.0.0.255":       ProxyVPN,
+			"1.0.1.0":         ProxyTOR,
+			"1.0.1.2":         ProxyNOT,
+			"1.255.255.255":   ProxyNOT,
+			"2.0.0.0":         ProxyDCH,
+			"2.0.0.255":       ProxyDCH,
+			"2.0.1.1":         ProxyNOT,
+			"3.0.0.128":       ProxyPUB,
+			"4.0.0.0":         ProxyWEB,
+			"4.0.0.2":         ProxyNOT,
+			"255.255.254.255": ProxyNOT,
+			"255.255.255.1":   ProxyVPN,
+			"255.255.255.254": ProxyVPN,
+		}
+		for ip, expected := range list {
+			res, err := db.LookupIPV4Dot(ip)
+			Expect(err).To(BeNil())
+			Expect(res.Proxy).To(Equal(expected), ip)
+		}
+	})
+	It("should return all the records fields", func() {
+		res, err := db.LookupIPV4Dot("1.0.0.1")
+		Expect(err).To(BeNil())
+		Expect(res).To(Equal(&Result{
+			IP:          "1.0.0.1",
+			Country:     ptrStr("Australia"),
+			CountryCode: ptrStr("AU"),
+			Region:      ptrStr("Queensland"),
+			City:        ptrStr("Brisbane"),
+			ISP:         ptrStr("Sample VPN Ltd"),
+			Proxy:       ProxyVPN,
+			Domain:      ptrStr("samplevpn.example"),
+			UsageType:   ptrStr("DCH"),
+			ASN:         ptrStr("13335"),
+			AS:          ptrStr("Sample VPN AS"),
+			LastSeen:    ptrStr("7"),
+			Provider:    ptrStr("SampleVPN"),
+			RawProxy:    ptrStr("VPN"),
+			Source:      source,
+		}))
+		res, err = db.LookupIPV4Dot("4.0.0.0")
+		Expect(err).To(BeNil())
+		Expect(res).To(Equal(&Result{
+			IP:          "4.0.0.0",
+			Country:     ptrStr("United Kingdom of Great Britain and Northern Ireland"),
+			CountryCode: ptrStr("GB"),
+			Region:      ptrStr("England"),
+			City:        ptrStr("London"),
+			ISP:         ptrStr("Web Proxy Inc"),
+			Proxy:       ProxyWEB,
+			Domain:      ptrStr("webproxy.example"),
+			UsageType:   ptrStr("COM"),
+			ASN:         ptrStr("64502"),
+			AS:          ptrStr("WEB-PROXY-INC"),
+			LastSeen:    ptrStr("12"),
+			Threat:      ptrStr("BOTNET"),
+			RawProxy:    ptrStr("WEB"),
+			Source:      source,
+		}))
+	})
+	It("should check the db age", func() {
+		_, err := Open(filepath.Join("testdata", "PX11-SAMPLE.BIN"), WithMaxAge(24*time.Hour))
+		Expect(err).To(MatchError("PX11-2021-06-01 older than 24h0m0s: db expired"))
+		Expect(errors.Cause(err)).To(Equal(ErrExpired))
+		_, err = Open(filepath.Join("testdata", "PX11-SAMPLE.BIN"), WithMaxAge(100*365*24*time.Hour))
+		Expect(err).To(BeNil())
+	})
+	It("should read the proxies last seen too long ago as non proxies", func() {
+		expiringDB, err := Open(filepath.Join("testdata", "PX11-SAMPLE.BIN"), WithMaxLastSeen(10))
+		Expect(err).To(BeNil())
+		res, err := expiringDB.LookupIPV4Dot("4.0.0.0")
+		Expect(err).To(BeNil())
+		Expect(res.Proxy).To(Equal(ProxyNOT))
+		Expect(*res.RawProxy).To(Equal("WEB"))
+		days, ok := res.LastSeenDays()
+		Expect(ok).To(BeTrue())
+		Expect(days).To(Equal(12))
+		res, err = expiringDB.LookupIPV4Dot("1.0.0.1")
+		Expect(err).To(BeNil())
+		Expect(res.Proxy).To(Equal(ProxyVPN))
+		res, err = db.LookupIPV4Dot("4.0.0.0")
+		Expect(err).To(BeNil())
+		Expect(res.Proxy).To(Equal(ProxyWEB))
+	})
+	It("should check the db digest", func() {
+		path := filepath.Join("testdata", "PX11-SAMPLE.BIN")
+		sum := "2597686c4b53f2bbb516d49750fb02a7e7c69b228d1df168b7622997585b4089"
+		_, err := Open(path, WithExpectedSHA256(sum))
+		Expect(err).To(BeNil())
+		_, err = Open(path, WithExpectedSHA256(strings.ToUpper(sum)))
+		Expect(err).To(BeNil())
+		other := "0000000000000000000000000000000000000000000000000000000000000000"
+		_, err = Open(path, WithExpectedSHA256(other))
+		Expect(err).To(MatchError("sha256 " + sum + ", expected " + other + ": checksum mismatch"))
+		Expect(errors.Cause(err)).To(Equal(ErrChecksumMismatch))
+		_, err = Open(path, WithExpectedSHA256("e31abc"))
+		Expect(err).To(MatchError(`invalid expected sha256 "e31abc"`))
+	})
+	It("should compare the fields of two addrs", func() {
+		diff, err := db.CompareIPs(net.ParseIP("1.0.0.255"), net.ParseIP("1.0.1.0"))
+		Expect(err).To(BeNil())
+		Expect(diff).To(HaveKeyWithValue(FieldProxy, [2]*string{ptrStr("VPN"), ptrStr("TOR")}))
+		Expect(diff).To(HaveKeyWithValue(FieldDomain, [2]*string{ptrStr("samplevpn.example"), nil}))
+		Expect(diff).NotTo(HaveKey(FieldCountryRaw))
+		diff, err = db.CompareIPs(net.ParseIP("1.0.0.1"), net.ParseIP("1.0.0.2"))
+		Expect(err).To(BeNil())
+		Expect(diff).To(BeEmpty())
+		_, err = db.CompareIPs(net.ParseIP("1.0.0.1"), nil)
+		Expect(err).To(MatchError("invalid IP"))
+	})
+	It("should return flat results", func() {
+		res, found, err := db.LookupFlat(net.ParseIP("1.0.1.0"))
+		Expect(err).To(BeNil())
+		Expect(found).To(BeTrue())
+		Expect(res).To(Equal(FlatResult{
+			IP:          "1.0.1.0",
+			Country:     "Germany",
+			CountryCode: "DE",
+			Region:      "Berlin",
+			City:        "Berlin",
+			ISP:         "Tor Exit Relay",
+			Proxy:       ProxyTOR,
+			LastSeen:    "1",
+			Threat:      "SPAM",
+			RawProxy:    "TOR",
+		}))
+		_, found, err = db.LookupFlat(nil)
+		Expect(err).To(HaveOccurred())
+		Expect(found).To(BeFalse())
+	})
+	It("should return the ISO country names", func() {
+		db, err := Open(filepath.Join("testdata", "PX11-SAMPLE.BIN"), WithISOCountryNames())
+		Expect(err).To(BeNil())
+		res, err := db.LookupIPV4Dot("2.0.0.1")
+		Expect(err).To(BeNil())
+		Expect(*res.Country).To(Equal("United States of America"))
+		Expect(*res.CountryCode).To(Equal("US"))
+		Expect(*res.CountryRaw).To(Equal("United States of America"))
+		res, err = db.LookupIPV4Dot("255.255.255.1")
+		Expect(err).To(BeNil())
+		Expect(*res.Country).To(Equal("Netherlands"))
+		lite, err := Open(filepath.Join("testdata", "IP2PROXY-LITE-PX4.BIN"), WithISOCountryNames())
+		Expect(err).To(BeNil())
+		res, err = lite.LookupIPV4Dot("31.31.77.107")
+		Expect(err).To(BeNil())
+		Expect(*res.Country).To(Equal("Czechia"))
+		Expect(*res.CountryRaw).To(Equal("Czech Republic"))
+		res, err = db.LookupIPV4Dot("9.9.9.9")
+		Expect(err).To(BeNil())
+		Expect(res.Country).To(BeNil())
+		Expect(res.CountryRaw).To(BeNil())
+	})
+	It("should return the canonical ISP names", func() {
+		canonicalDB, err := Open(filepath.Join("testdata", "PX11-SAMPLE.BIN"), WithISPCanonicalization())
+		Expect(err).To(BeNil())
+		res, err := canonicalDB.LookupIPV4Dot("1.0.0.1")
+		Expect(err).To(BeNil())
+		Expect(*res.ISP).To(Equal("Sample VPN Ltd"))
+		Expect(*res.ISPCanonical).To(Equal("sample vpn"))
+		res, err = canonicalDB.LookupIPV4Dot("9.9.9.9")
+		Expect(err).To(BeNil())
+		Expect(res.ISPCanonical).To(BeNil())
+		res, err = db.LookupIPV4Dot("1.0.0.1")
+		Expect(err).To(BeNil())
+		Expect(res.ISPCanonical).To(BeNil())
+	})
+	It("should canonicalize the ISP names", func() {
+		for isp, canonical := range map[string]string{
+			"Example Networks, Inc.":    "example networks",
+			"EXAMPLE  networks":         "example networks",
+			"Telefonica de Espana S.A.": "telefonica de espana",
+			"Telenor ASA":               "telenor",
+			"Foo Holding , Co., Ltd":    "foo holding",
+			"Inc":                       "inc",
+			" ":                         "",
+		} {
+			Expect(CanonicalISP(isp)).To(Equal(canonical), isp)
+		}
+	})
+	It("should return empty strings instead of nil fields", func() {
+		emptyDB, err := Open(filepath.Join("testdata", "PX11-SAMPLE.BIN"), WithEmptyInsteadOfNil())
+		Expect(err).To(BeNil())
+		res, err := emptyDB.LookupIPV4Dot("9.9.9.9")
+		Expect(err).To(BeNil())
+		for f := FieldCountry; f <= FieldISPCanonical; f++ {
+			if f == FieldProxy {
+				continue
+			}
+			v, ok := res.Map()[f.String()]
+			Expect(ok).To(BeTrue(), f.String())
+			if f != FieldRawProxy {
+				Expect(v).To(Equal(""), f.String())
+			}
+		}
+		res, err = emptyDB.LookupIPV4Dot("1.0.0.1")
+		Expect(err).To(BeNil())
+		Expect(*res.ISP).To(Equal("Sample VPN Ltd"))
+		Expect(*res.Threat).To(Equal(""))
+	})
+	It("should leave out the empty and placeholder fields", func() {
+		res, err := db.LookupIPV4Dot("1.0.1.0")
+		Expect(err).To(BeNil())
+		Expect(res).To(Equal(&Result{
+			IP:          "1.0.1.0",
+			Country:     ptrStr("Germany"),
+			CountryCode: ptrStr("DE"),
+			Region:      ptrStr("Berlin"),
+			City:        ptrStr("Berlin"),
+			ISP:         ptrStr("Tor Exit Relay"),
+			Proxy:       ProxyTOR,
+			LastSeen:    ptrStr("1"),
+			Threat:      ptrStr("SPAM"),
+			RawProxy:    ptrStr("TOR"),
+			Source:      source,
+		}))
+		res, err = db.LookupIPV4Dot("3.0.0.0")
+		Expect(err).To(BeNil())
+		Expect(res.Domain).To(BeNil())
+		Expect(res.Provider).To(BeNil())
+		Expect(res.UsageType).To(Equal(ptrStr("ISP/MOB")))
+		res, err = db.LookupIPV4Dot("9.9.9.9")
+		Expect(err).To(BeNil())
+		Expect(res).To(Equal(&Result{IP: "9.9.9.9", Proxy: ProxyNOT, RawProxy: ptrStr("-"), Source: source}))
+	})
+})
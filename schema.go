@@ -0,0 +1,32 @@
+package ip2proxy
+
+// Schema describes the columns of a db, see DB.Schema
+type Schema struct {
+	Type     DbType         `json:"type"`
+	TypeName string         `json:"type_name"`
+	Version  string         `json:"version"`
+	Columns  []SchemaColumn `json:"columns"`
+}
+
+// SchemaColumn describes a column of a db, a column holding several fields being described once per field
+type SchemaColumn struct {
+	// Name is the json name of the field held by the column, "ip_from" for the first column
+	Name string `json:"name"`
+	// Column is the column number, from 1
+	Column int `json:"column"`
+	// Type is the type of the column values: "uint32" for the first column, "string" for the other ones
+	Type string `json:"type"`
+}
+
+// Schema returns the description of the db columns, in their order, according to its type and column layout (see
+// WithColumnLayout). The country column is described as its country code and name fields.
+func (db *DB) Schema() Schema {
+	columns := []SchemaColumn{{Name: "ip_from", Column: 1, Type: "uint32"}}
+	for _, f := range db.csvColumns() {
+		if f == FieldRawProxy {
+			f = FieldProxy
+		}
+		columns = append(columns, SchemaColumn{Name: f.String(), Column: int(*db.positions.field(f)>>2) + 1, Type: "string"})
+	}
+	return Schema{Type: db.Type(), TypeName: db.TypeName(), Version: db.Version(), Columns: columns}
+}
@@ -0,0 +1,50 @@
+package ip2proxy_test
+
+import (
+	"encoding/json"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/etf1/ip2proxy"
+)
+
+var _ = Describe("Schema", func() {
+	It("should describe the db columns", func() {
+		db, err := Open(filepath.Join("testdata", "IP2PROXY-LITE-PX4.BIN"))
+		Expect(err).To(BeNil())
+		schema := db.Schema()
+		Expect(schema).To(Equal(Schema{
+			Type:     PX4,
+			TypeName: "PX4",
+			Version:  "PX4-2018-02-01",
+			Columns: []SchemaColumn{
+				{Name: "ip_from", Column: 1, Type: "uint32"},
+				{Name: "proxy", Column: 2, Type: "string"},
+				{Name: "country_code", Column: 3, Type: "string"},
+				{Name: "country", Column: 3, Type: "string"},
+				{Name: "region", Column: 4, Type: "string"},
+				{Name: "city", Column: 5, Type: "string"},
+				{Name: "isp", Column: 6, Type: "string"},
+			},
+		}))
+		data, err := json.Marshal(schema)
+		Expect(err).To(BeNil())
+		Expect(string(data)).To(HavePrefix(
+			`{"type":4,"type_name":"PX4","version":"PX4-2018-02-01","columns":[{"name":"ip_from","column":1,"type":"uint32"},`,
+		))
+	})
+	It("should follow the column layout", func() {
+		db, err := Open(filepath.Join("testdata", "IP2PROXY-LITE-PX4.BIN"), WithColumnLayout(map[Field]uint8{
+			FieldISP:    4,
+			FieldRegion: 6,
+		}))
+		Expect(err).To(BeNil())
+		var names []string
+		for _, c := range db.Schema().Columns {
+			names = append(names, c.Name)
+		}
+		Expect(names).To(Equal([]string{"ip_from", "proxy", "country_code", "country", "isp", "city", "region"}))
+	})
+})
@@ -0,0 +1,91 @@
+package ip2proxy
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"math"
+
+	"github.com/juju/errors"
+)
+
+// DataSource provides the content of a db file to OpenSource, e.g. from a file, a reader or a filesystem abstraction
+// implementing fs.FS such as afero's
+type DataSource interface {
+	// ReadData returns the whole content of the db file, which the db keeps and must not be modified after
+	ReadData() ([]byte, error)
+}
+
+// DataSourceFunc is a function implementing DataSource
+type DataSourceFunc func() ([]byte, error)
+
+// ReadData calls f
+func (f DataSourceFunc) ReadData() ([]byte, error) {
+	return f()
+}
+
+// OpenSource reads a db file from src and parses it. Open, OpenReader and the other constructors reading a whole file
+// are wrappers over it.
+func OpenSource(src DataSource, opts ...Option) (*DB, error) {
+	data, err := src.ReadData()
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot open/read db file")
+	}
+	return FromBytes(data, opts...)
+}
+
+// BytesSource is the source of a db file held in data
+func BytesSource(data []byte) DataSource {
+	return DataSourceFunc(func() ([]byte, error) {
+		return data, nil
+	})
+}
+
+// FileSource is the source of the db file at path
+func FileSource(path string) DataSource {
+	return DataSourceFunc(func() ([]byte, error) {
+		data, err := ioutil.ReadFile(path)
+		if err == nil && len(data) == 0 {
+			err = fmt.Errorf("%s is empty or not redable", path)
+		}
+		return data, err
+	})
+}
+
+// ReaderSource is the source of a db file read from r until EOF
+func ReaderSource(r io.Reader) DataSource {
+	return DataSourceFunc(func() ([]byte, error) {
+		return ioutil.ReadAll(r)
+	})
+}
+
+// ReaderAtSource is the source of a db file of size bytes read from r. A size smaller than the db header or beyond the
+// 4GB addressable by the db offsets is an error, returned before reading.
+func ReaderAtSource(r io.ReaderAt, size int64) DataSource {
+	return DataSourceFunc(func() ([]byte, error) {
+		if size < headerSize {
+			return nil, fmt.Errorf("invalid size %d, smaller than the %d bytes header", size, headerSize)
+		}
+		if size > math.MaxUint32 {
+			return nil, fmt.Errorf("invalid size %d, beyond the 4GB addressable by the db offsets", size)
+		}
+		data := make([]byte, size)
+		n, err := r.ReadAt(data, 0)
+		if n == len(data) {
+			return data, nil
+		}
+		return nil, err
+	})
+}
+
+// FSSource is the source of the db file name in the filesystem fsys
+func FSSource(fsys fs.FS, name string) DataSource {
+	return DataSourceFunc(func() ([]byte, error) {
+		data, err := fs.ReadFile(fsys, name)
+		if err == nil && len(data) == 0 {
+			err = fmt.Errorf("%s is empty or not redable", name)
+		}
+		return data, err
+	})
+}
@@ -0,0 +1,57 @@
+package ip2proxy_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing/fstest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/etf1/ip2proxy"
+)
+
+var _ = Describe("OpenSource", func() {
+	var data []byte
+	BeforeEach(func() {
+		var err error
+		data, err = ioutil.ReadFile(filepath.Join("testdata", "PX11-SAMPLE.BIN"))
+		Expect(err).To(BeNil())
+	})
+	It("should open the db of each source", func() {
+		for name, src := range map[string]DataSource{
+			"bytes":    BytesSource(data),
+			"file":     FileSource(filepath.Join("testdata", "PX11-SAMPLE.BIN")),
+			"reader":   ReaderSource(bytes.NewReader(data)),
+			"readerAt": ReaderAtSource(bytes.NewReader(data), int64(len(data))),
+			"fs":       FSSource(os.DirFS("testdata"), "PX11-SAMPLE.BIN"),
+			"mapfs":    FSSource(fstest.MapFS{"db.bin": {Data: data}}, "db.bin"),
+		} {
+			db, err := OpenSource(src)
+			Expect(err).To(BeNil(), name)
+			res, err := db.LookupIPV4Dot("1.0.0.1")
+			Expect(err).To(BeNil(), name)
+			Expect(res.Proxy).To(Equal(ProxyVPN), name)
+		}
+	})
+	It("should return the source errors", func() {
+		_, err := OpenSource(DataSourceFunc(func() ([]byte, error) { return nil, errors.New("unreachable") }))
+		Expect(err).To(MatchError("cannot open/read db file: unreachable"))
+		_, err = OpenSource(ReaderAtSource(bytes.NewReader(data), int64(len(data))+1))
+		Expect(err).To(MatchError("cannot open/read db file: EOF"))
+		_, err = OpenSource(ReaderAtSource(bytes.NewReader(data), -1))
+		Expect(err).To(MatchError("cannot open/read db file: invalid size -1, smaller than the 64 bytes header"))
+		_, err = OpenSource(ReaderAtSource(bytes.NewReader(data), 63))
+		Expect(err).To(MatchError("cannot open/read db file: invalid size 63, smaller than the 64 bytes header"))
+		_, err = OpenSource(ReaderAtSource(bytes.NewReader(data), math.MaxUint32+1))
+		Expect(err).To(MatchError(
+			"cannot open/read db file: invalid size 4294967296, beyond the 4GB addressable by the db offsets",
+		))
+		_, err = OpenSource(FSSource(fstest.MapFS{"empty": {}}, "empty"))
+		Expect(err).To(MatchError("cannot open/read db file: empty is empty or not redable"))
+	})
+})
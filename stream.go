@@ -0,0 +1,156 @@
+package ip2proxy
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// OutputFormat is a format of results written to a stream
+type OutputFormat uint8
+
+const (
+	// FormatJSONL writes a json object per result line
+	FormatJSONL OutputFormat = iota
+	// FormatCSV writes a csv record per result, after a header record naming the columns
+	FormatCSV
+)
+
+// csv columns of written results, followed by an error column
+var csvFields = []Field{
+	FieldProxy,
+	FieldCountryCode,
+	FieldCountry,
+	FieldRegion,
+	FieldCity,
+	FieldISP,
+	FieldDomain,
+	FieldUsageType,
+	FieldASN,
+	FieldAS,
+	FieldLastSeen,
+	FieldThreat,
+	FieldProvider,
+}
+
+// ClassifyStream reads ipv4 addrs from r, one per line, lookups each of them and writes the results to w in format.
+// Blank lines are skipped. Malformed lines, ipv6 addrs included, don't stop the stream: they are written with the
+// error, in an "error" json field or csv column, the line being written as the ip.
+func (db *DB) ClassifyStream(r io.Reader, w io.Writer, format OutputFormat) error {
+	var out resultWriter
+	switch format {
+	case FormatJSONL:
+		out = newJSONLWriter(w)
+	case FormatCSV:
+		out = newCSVWriter(w)
+	default:
+		return fmt.Errorf("unknown output format %d", format)
+	}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		res, err := db.lookupNetIPV4(net.ParseIP(line))
+		if err != nil || res == nil {
+			res = &Result{IP: line}
+		}
+		if err := out.write(res, err); err != nil {
+			return errors.Annotate(err, "cannot write result")
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return errors.Annotate(err, "cannot read stream")
+	}
+	return errors.Annotate(out.flush(), "cannot write result")
+}
+
+// writes results in a format
+type resultWriter interface {
+	write(res *Result, lookupErr error) error
+	flush() error
+}
+
+// writes results as json lines
+type jsonlWriter struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+// creates a json lines results writer
+func newJSONLWriter(w io.Writer) *jsonlWriter {
+	buf := bufio.NewWriter(w)
+	return &jsonlWriter{buf: buf, enc: json.NewEncoder(buf)}
+}
+
+func (w *jsonlWriter) write(res *Result, lookupErr error) error {
+	if lookupErr != nil {
+		return w.enc.Encode(struct {
+			IP    string `json:"ip"`
+			Error string `json:"error"`
+		}{res.IP, lookupErr.Error()})
+	}
+	return w.enc.Encode(res)
+}
+
+func (w *jsonlWriter) flush() error {
+	return w.buf.Flush()
+}
+
+// writes results as csv records
+type csvWriter struct {
+	csv    *csv.Writer
+	header bool
+}
+
+// creates a csv results writer
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{csv: csv.NewWriter(w)}
+}
+
+func (w *csvWriter) write(res *Result, lookupErr error) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	record := []string{res.IP}
+	for _, f := range csvFields {
+		v, _ := f.value(res)
+		if lookupErr != nil {
+			v = ""
+		}
+		record = append(record, v)
+	}
+	errStr := ""
+	if lookupErr != nil {
+		errStr = lookupErr.Error()
+	}
+	return w.csv.Write(append(record, errStr))
+}
+
+func (w *csvWriter) flush() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+// writes the header record, once
+func (w *csvWriter) writeHeader() error {
+	if w.header {
+		return nil
+	}
+	w.header = true
+	header := []string{"ip"}
+	for _, f := range csvFields {
+		header = append(header, f.String())
+	}
+	return w.csv.Write(append(header, "error"))
+}
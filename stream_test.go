@@ -0,0 +1,64 @@
+package ip2proxy_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/etf1/ip2proxy"
+)
+
+var _ = Describe("Stream", func() {
+	input := "1.0.1.0\n\n  9.9.9.9  \nlol\n4.0.0.0\n"
+	var db *DB
+	BeforeEach(func() {
+		var err error
+		db, err = Open(filepath.Join("testdata", "PX11-SAMPLE.BIN"))
+		Expect(err).To(BeNil())
+	})
+	It("should write json lines", func() {
+		var buf bytes.Buffer
+		Expect(db.ClassifyStream(strings.NewReader(input), &buf, FormatJSONL)).To(Succeed())
+		Expect(strings.Split(buf.String(), "\n")).To(Equal([]string{
+			`{"schema_version":1,"ip":"1.0.1.0","country":"Germany","country_code":"DE","city":"Berlin","isp":"Tor Exit Relay","region":"Berlin","proxy":"TOR","last_seen":"1","threat":"SPAM","raw_proxy":"TOR","source":{"type":11,"version":"PX11-2021-06-01"}}`,
+			`{"schema_version":1,"ip":"9.9.9.9","proxy":"NOT","raw_proxy":"-","source":{"type":11,"version":"PX11-2021-06-01"}}`,
+			`{"ip":"lol","error":"invalid IP"}`,
+			`{"schema_version":1,"ip":"4.0.0.0","country":"United Kingdom of Great Britain and Northern Ireland","country_code":"GB","city":"London","isp":"Web Proxy Inc","region":"England","proxy":"WEB","domain":"webproxy.example","usage_type":"COM","asn":"64502","as":"WEB-PROXY-INC","last_seen":"12","threat":"BOTNET","raw_proxy":"WEB","source":{"type":11,"version":"PX11-2021-06-01"}}`,
+			``,
+		}))
+	})
+	It("should write csv records", func() {
+		var buf bytes.Buffer
+		Expect(db.ClassifyStream(strings.NewReader(input), &buf, FormatCSV)).To(Succeed())
+		Expect(strings.Split(buf.String(), "\n")).To(Equal([]string{
+			"ip,proxy,country_code,country,region,city,isp,domain,usage_type,asn,as,last_seen,threat,provider,error",
+			"1.0.1.0,TOR,DE,Germany,Berlin,Berlin,Tor Exit Relay,,,,,1,SPAM,,",
+			"9.9.9.9,NOT,,,,,,,,,,,,,",
+			"lol,,,,,,,,,,,,,,invalid IP",
+			"4.0.0.0,WEB,GB,United Kingdom of Great Britain and Northern Ireland,England,London,Web Proxy Inc,webproxy.example,COM,64502,WEB-PROXY-INC,12,BOTNET,,",
+			"",
+		}))
+	})
+	It("should write ipv6 addrs as invalid", func() {
+		var buf bytes.Buffer
+		// its low bits being the ipv4 addr 1.0.0.1 of a VPN record
+		Expect(db.ClassifyStream(strings.NewReader("2001:db8::100:1\n::ffff:1.0.1.0\n"), &buf, FormatCSV)).To(Succeed())
+		Expect(strings.Split(buf.String(), "\n")).To(Equal([]string{
+			"ip,proxy,country_code,country,region,city,isp,domain,usage_type,asn,as,last_seen,threat,provider,error",
+			"2001:db8::100:1,,,,,,,,,,,,,,invalid IP",
+			"1.0.1.0,TOR,DE,Germany,Berlin,Berlin,Tor Exit Relay,,,,,1,SPAM,,",
+			"",
+		}))
+	})
+	It("should write the csv header of an empty stream", func() {
+		var buf bytes.Buffer
+		Expect(db.ClassifyStream(strings.NewReader(""), &buf, FormatCSV)).To(Succeed())
+		Expect(buf.String()).To(HavePrefix("ip,proxy,"))
+	})
+	It("should return an error for an unknown format", func() {
+		Expect(db.ClassifyStream(strings.NewReader(input), &bytes.Buffer{}, OutputFormat(42))).To(MatchError("unknown output format 42"))
+	})
+})
@@ -0,0 +1,53 @@
+package ip2proxy
+
+import "strconv"
+
+// Tracer starts a span around each lookup of a single addr, see WithTracer. It is meant to be implemented over a
+// tracing library such as OpenTelemetry, which the package does not depend on.
+type Tracer interface {
+	// StartSpan starts the span named name
+	StartSpan(name string) Span
+}
+
+// Span is a span started by a Tracer
+type Span interface {
+	// SetAttribute sets the attribute key of the span to value
+	SetAttribute(key, value string)
+	// RecordError records the error ending the span
+	RecordError(err error)
+	// End ends the span
+	End()
+}
+
+// names of the lookup span and of its attributes
+const (
+	lookupSpan        = "ip2proxy.lookup"
+	spanAttrIP        = "ip2proxy.ip"
+	spanAttrFound     = "ip2proxy.found"
+	spanAttrRange     = "ip2proxy.range"
+	spanAttrProxyType = "ip2proxy.proxy_type"
+)
+
+// lookups a record in db for an ipv4 addr as lookupIPV4Into does, in a span of the tracer
+func (db *DB) tracedLookupIPV4Into(ip uint32, res *Result) (bool, error) {
+	span := db.opts.tracer.StartSpan(lookupSpan)
+	defer span.End()
+	span.SetAttribute(spanAttrIP, intToIPV4(ip))
+	pos, found, err := db.lookupIPV4Row(ip, res)
+	if err != nil {
+		span.RecordError(err)
+		return false, err
+	}
+	span.SetAttribute(spanAttrFound, strconv.FormatBool(found))
+	if !found {
+		return false, nil
+	}
+	span.SetAttribute(spanAttrProxyType, res.Proxy.String())
+	if pos != 0 {
+		// the range is only read when tracing, single lookups not needing it
+		if from, to, err := db.rowRange(db.rowIndex(pos)); err == nil {
+			span.SetAttribute(spanAttrRange, Range{From: from, To: to}.String())
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,69 @@
+package ip2proxy_test
+
+import (
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/etf1/ip2proxy"
+)
+
+// records the spans
+type testTracer struct {
+	spans []*testSpan
+}
+
+func (t *testTracer) StartSpan(name string) Span {
+	span := &testSpan{name: name, attrs: map[string]string{}}
+	t.spans = append(t.spans, span)
+	return span
+}
+
+type testSpan struct {
+	name  string
+	attrs map[string]string
+	err   error
+	ended bool
+}
+
+func (s *testSpan) SetAttribute(key, value string) {
+	s.attrs[key] = value
+}
+
+func (s *testSpan) RecordError(err error) {
+	s.err = err
+}
+
+func (s *testSpan) End() {
+	s.ended = true
+}
+
+var _ = Describe("Tracer", func() {
+	It("should start a span per lookup", func() {
+		tracer := &testTracer{}
+		db, err := Open(filepath.Join("testdata", "PX11-SAMPLE.BIN"), WithTracer(tracer), WithReserved(nil))
+		Expect(err).To(BeNil())
+		_, err = db.LookupIPV4Dot("1.0.0.1")
+		Expect(err).To(BeNil())
+		_, err = db.LookupIPV4Dot("10.0.0.1")
+		Expect(err).To(Equal(ErrReserved))
+		Expect(tracer.spans).To(HaveLen(2))
+		Expect(*tracer.spans[0]).To(Equal(testSpan{
+			name: "ip2proxy.lookup",
+			attrs: map[string]string{
+				"ip2proxy.ip":         "1.0.0.1",
+				"ip2proxy.found":      "true",
+				"ip2proxy.proxy_type": "VPN",
+				"ip2proxy.range":      "1.0.0.0-1.0.0.255",
+			},
+			ended: true,
+		}))
+		Expect(*tracer.spans[1]).To(Equal(testSpan{
+			name:  "ip2proxy.lookup",
+			attrs: map[string]string{"ip2proxy.ip": "10.0.0.1"},
+			err:   ErrReserved,
+			ended: true,
+		}))
+	})
+})
@@ -0,0 +1,99 @@
+package ip2proxy
+
+import "strings"
+
+// UsageType is a set of usage types of an addr, as held by the usage type field: an addr may have several of them,
+// such as ISP/MOB for a fixed line and mobile ISP
+type UsageType uint16
+
+const (
+	// UsageCOM is the commercial usage type
+	UsageCOM UsageType = 1 << iota
+	// UsageORG is the organization usage type
+	UsageORG
+	// UsageGOV is the government usage type
+	UsageGOV
+	// UsageMIL is the military usage type
+	UsageMIL
+	// UsageEDU is the university, college or school usage type
+	UsageEDU
+	// UsageLIB is the library usage type
+	UsageLIB
+	// UsageCDN is the content delivery network usage type
+	UsageCDN
+	// UsageISP is the fixed line ISP usage type
+	UsageISP
+	// UsageMOB is the mobile ISP usage type
+	UsageMOB
+	// UsageDCH is the data center, web hosting or transit usage type
+	UsageDCH
+	// UsageSES is the search engine spider usage type
+	UsageSES
+	// UsageRSV is the reserved usage type
+	UsageRSV
+)
+
+// usage types names, in the order of their values
+var usageTypeNames = []string{"COM", "ORG", "GOV", "MIL", "EDU", "LIB", "CDN", "ISP", "MOB", "DCH", "SES", "RSV"}
+
+// ParseUsageType parses a usage type field value, the names of its usage types being separated by slashes as in
+// ISP/MOB. ok is false when a name is unknown, the known ones being returned.
+func ParseUsageType(s string) (t UsageType, ok bool) {
+	ok = true
+	for _, name := range strings.Split(s, "/") {
+		found := false
+		for i, n := range usageTypeNames {
+			if n == name {
+				t |= 1 << uint(i)
+				found = true
+			}
+		}
+		ok = ok && found
+	}
+	return t, ok
+}
+
+// String returns the names of the usage types separated by slashes, in the order of their values
+func (t UsageType) String() string {
+	var names []string
+	for i, name := range usageTypeNames {
+		if t&(1<<uint(i)) != 0 {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, "/")
+}
+
+// Has checks if t holds all the usage types of other
+func (t UsageType) Has(other UsageType) bool {
+	return t&other == other
+}
+
+// IsMobile checks if t holds the mobile ISP usage type
+func (t UsageType) IsMobile() bool {
+	return t.Has(UsageMOB)
+}
+
+// IsGovernment checks if t holds the government or military usage type
+func (t UsageType) IsGovernment() bool {
+	return t&(UsageGOV|UsageMIL) != 0
+}
+
+// IsHosting checks if t holds the data center or content delivery network usage type
+func (t UsageType) IsHosting() bool {
+	return t&(UsageDCH|UsageCDN) != 0
+}
+
+// IsEducation checks if t holds the university, college or school usage type, or the library one
+func (t UsageType) IsEducation() bool {
+	return t&(UsageEDU|UsageLIB) != 0
+}
+
+// Usage returns the usage types of the result, parsed from its UsageType field. ok is false when the field is absent
+// or holds an unknown usage type, which is then only held by the raw UsageType field.
+func (r *Result) Usage() (t UsageType, ok bool) {
+	if r.UsageType == nil {
+		return 0, false
+	}
+	return ParseUsageType(*r.UsageType)
+}
@@ -0,0 +1,99 @@
+package ip2proxy
+
+import (
+	"fmt"
+
+	"github.com/juju/errors"
+)
+
+// Verify checks the whole db consistency: records must be sorted by ascending ipFrom and each of them must be
+// readable, a record starting at the same addr as the next one being empty rather than corrupt. The index buckets of a sample of the addrs blocks sharing their 16 top bits are also checked to span
+// the rows holding their block. It returns an error describing the first inconsistency found.
+//
+// As a record range ends where the next record starts, adjacent records share their boundary addr, which lookups
+// resolve to the first record their search meets. Records out of order make ranges overlap beyond that boundary,
+// lookups then returning the record with the lowest ipFrom, and Verify should be used to reject such files.
+func (db *DB) Verify() error {
+	if err := db.checkRecords(); err != nil {
+		return err
+	}
+	var prev uint32
+	for i := uint32(0); i < db.header.Count; i++ {
+		ipFrom, err := db.readUint32(db.rowOffset(i))
+		if err != nil {
+			return errors.Annotatef(err, "cannot read record %d", i)
+		}
+		if i > 0 && ipFrom < prev {
+			return fmt.Errorf(
+				"record %d starting at %s overlaps record %d starting at %s",
+				i,
+				intToIPV4(ipFrom),
+				i-1,
+				intToIPV4(prev),
+			)
+		}
+		prev = ipFrom
+		if i == db.header.Count-1 {
+			// last record only holds the upper bound of the previous one
+			break
+		}
+		if _, err := db.readIPV4Record(recordOffset(db.ipv4(), i)); err != nil {
+			return errors.Annotatef(err, "cannot read record %d", i)
+		}
+	}
+	return db.verifyIndex()
+}
+
+// stride between the index buckets checked by Verify, a prime spreading them over the addrs classes
+const verifyIndexStride = 251
+
+// checks that a sample of the index buckets span the rows holding their addrs: the first row of a bucket must start at
+// or before its first addr, unless it is the first record, and the row following its last row must start at or after
+// its last addr, unless its last row is the last one
+func (db *DB) verifyIndex() error {
+	readFrom := func(i uint32) (uint32, error) {
+		ip, err := db.readUint32(db.rowOffset(i))
+		return ip, errors.Annotatef(err, "cannot read record %d", i)
+	}
+	for b := uint32(0); b < maxIndexes; b += verifyIndexStride {
+		if b+verifyIndexStride >= maxIndexes {
+			// the last bucket is always checked
+			b = maxIndexes - 1
+		}
+		start, end, err := db.ipv4Index(b)
+		if err != nil {
+			return err
+		}
+		first, last := b<<16, b<<16|0xFFFF
+		ipFrom, err := readFrom(start)
+		if err != nil {
+			return err
+		}
+		if start > 0 && ipFrom > first {
+			return fmt.Errorf(
+				"index bucket %d first row %d starts at %s, after the bucket first addr %s",
+				b,
+				start,
+				intToIPV4(ipFrom),
+				intToIPV4(first),
+			)
+		}
+		if end+1 >= db.header.Count {
+			continue
+		}
+		ipTo, err := readFrom(end + 1)
+		if err != nil {
+			return err
+		}
+		if ipTo < last {
+			return fmt.Errorf(
+				"index bucket %d last row %d ends at %s, before the bucket last addr %s",
+				b,
+				end,
+				intToIPV4(ipTo),
+				intToIPV4(last),
+			)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package ip2proxy_test
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/etf1/ip2proxy"
+)
+
+var _ = Describe("Verify", func() {
+	It("should succeed on a valid file", func() {
+		db, err := Open(filepath.Join("testdata", "IP2PROXY-LITE-PX4.BIN"))
+		Expect(err).To(BeNil())
+		Expect(db.Verify()).To(Succeed())
+	})
+	It("should report overlapping records", func() {
+		b, err := ioutil.ReadFile(filepath.Join("testdata", "IP2PROXY-LITE-PX4.BIN"))
+		if err != nil {
+			Fail("could read db file")
+		}
+		// moves the start of the third record (1.0.1.0) before the second one (1.0.0.0)
+		binary.LittleEndian.PutUint32(b[1048640+2*24:], 16777215)
+		db, err := FromBytes(b)
+		Expect(err).To(BeNil())
+		err = db.Verify()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("record 2 starting at 0.255.255.255 overlaps record 1 starting at 1.0.0.0"))
+	})
+	It("should report index buckets not spanning their rows", func() {
+		b, err := ioutil.ReadFile(filepath.Join("testdata", "PX11-SAMPLE.BIN"))
+		Expect(err).To(BeNil())
+		// the bucket of 0.0.0.0/16 pointing to the row of 1.0.1.1
+		binary.LittleEndian.PutUint32(b[64:], 3)
+		binary.LittleEndian.PutUint32(b[68:], 3)
+		db, err := FromBytes(b)
+		Expect(err).To(BeNil())
+		Expect(db.Verify()).To(MatchError("index bucket 0 first row 3 starts at 1.0.1.1, after the bucket first addr 0.0.0.0"))
+		b, err = ioutil.ReadFile(filepath.Join("testdata", "PX11-SAMPLE.BIN"))
+		Expect(err).To(BeNil())
+		// the bucket of 1.246.0.0/16 only spanning the row of 1.0.0.0
+		binary.LittleEndian.PutUint32(b[64+502*8:], 1)
+		binary.LittleEndian.PutUint32(b[64+502*8+4:], 1)
+		db, err = FromBytes(b)
+		Expect(err).To(BeNil())
+		Expect(db.Verify()).To(MatchError("index bucket 502 last row 1 ends at 1.0.1.0, before the bucket last addr 1.246.255.255"))
+	})
+})
@@ -0,0 +1,55 @@
+package ip2proxy
+
+import (
+	"archive/zip"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// OpenZip opens a db file held by the zip archive at path, as the IP2Proxy downloads are. The db file is the entry
+// named entryName, or the single .BIN entry of the archive if entryName is empty.
+func OpenZip(zipPath, entryName string, opts ...Option) (*DB, error) {
+	z, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot open/read zip file")
+	}
+	defer z.Close()
+	entry, err := findZipEntry(&z.Reader, entryName)
+	if err != nil {
+		return nil, errors.Annotatef(err, "cannot find db file in %s", zipPath)
+	}
+	r, err := entry.Open()
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot open/read db file")
+	}
+	defer r.Close()
+	return OpenReader(r, opts...)
+}
+
+// finds the zip entry named name, or the single .BIN entry if name is empty
+func findZipEntry(z *zip.Reader, name string) (*zip.File, error) {
+	var found *zip.File
+	for _, f := range z.File {
+		switch {
+		case name != "":
+			if f.Name == name {
+				return f, nil
+			}
+		case strings.EqualFold(path.Ext(f.Name), ".bin"):
+			if found != nil {
+				return nil, fmt.Errorf("several .BIN entries, %s and %s, an entry name is required", found.Name, f.Name)
+			}
+			found = f
+		}
+	}
+	if found == nil {
+		if name != "" {
+			return nil, fmt.Errorf("no %s entry", name)
+		}
+		return nil, fmt.Errorf("no .BIN entry")
+	}
+	return found, nil
+}
@@ -0,0 +1,70 @@
+package ip2proxy_test
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/etf1/ip2proxy"
+)
+
+var _ = Describe("Zip", func() {
+	var dir string
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "ip2proxy")
+		Expect(err).To(BeNil())
+	})
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+	// writes a zip archive holding the sample db under each of names, along with a license file
+	writeZip := func(names ...string) string {
+		sample, err := ioutil.ReadFile(filepath.Join("testdata", "PX11-SAMPLE.BIN"))
+		Expect(err).To(BeNil())
+		zipPath := filepath.Join(dir, "db.zip")
+		f, err := os.Create(zipPath)
+		Expect(err).To(BeNil())
+		defer f.Close()
+		w := zip.NewWriter(f)
+		entries := map[string][]byte{"LICENSE_LITE.TXT": []byte("license")}
+		for _, name := range names {
+			entries[name] = sample
+		}
+		for name, data := range entries {
+			e, err := w.Create(name)
+			Expect(err).To(BeNil())
+			_, err = e.Write(data)
+			Expect(err).To(BeNil())
+		}
+		Expect(w.Close()).To(Succeed())
+		return zipPath
+	}
+	It("should open the single db file of the archive", func() {
+		db, err := OpenZip(writeZip("IP2PROXY-LITE-PX11.BIN"), "")
+		Expect(err).To(BeNil())
+		Expect(db.Version()).To(Equal("PX11-2021-06-01"))
+	})
+	It("should open the named db file of the archive", func() {
+		db, err := OpenZip(writeZip("PX11.BIN", "other.bin"), "PX11.BIN")
+		Expect(err).To(BeNil())
+		Expect(db.Version()).To(Equal("PX11-2021-06-01"))
+	})
+	It("should return an error when the db file isn't found", func() {
+		zipPath := writeZip("PX11.BIN", "other.bin")
+		_, err := OpenZip(zipPath, "")
+		Expect(err).To(MatchError(HavePrefix("cannot find db file in " + zipPath + ": several .BIN entries")))
+		_, err = OpenZip(zipPath, "PX4.BIN")
+		Expect(err).To(MatchError("cannot find db file in " + zipPath + ": no PX4.BIN entry"))
+		_, err = OpenZip(writeZip(), "")
+		Expect(err).To(MatchError("cannot find db file in " + zipPath + ": no .BIN entry"))
+	})
+	It("should return an error for an invalid archive", func() {
+		_, err := OpenZip(filepath.Join("testdata", "random"), "")
+		Expect(err).To(MatchError("cannot open/read zip file: zip: not a valid zip file"))
+	})
+})